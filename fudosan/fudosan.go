@@ -0,0 +1,117 @@
+package fudosan
+
+import (
+	"fmt"
+	"strings"
+)
+
+type PropertyType string
+
+const (
+	PropertyUnknown  PropertyType = "不明"
+	PropertyLand     PropertyType = "土地"
+	PropertyBuilding PropertyType = "建物"
+)
+
+// Hyodaibu は表題部に記録された不動産の表示
+type Hyodaibu struct {
+	FudosanBangou string   // 不動産番号
+	Shozai        string   // 所在
+	Chiban        string   // 地番（土地）
+	KaokuBangou   string   // 家屋番号（建物）
+	Chimoku       string   // 地目（土地）
+	Chiseki       string   // 地積（土地）。単位は㎡
+	Shurui        string   // 種類（建物）
+	Kouzou        string   // 構造（建物）
+	Yukamenseki   []string // 床面積（建物）。階ごとに「1階 50.00」の形で持つ
+}
+
+// RightHolder は権利者その他の事項に記録された権利者
+type RightHolder struct {
+	Role    string // 所有者、抵当権者など
+	Name    string
+	Address string
+	Share   string // 持分
+}
+
+// Entry は権利部の1つの登記
+type Entry struct {
+	Rank          string // 順位番号
+	Purpose       string // 登記の目的
+	ReceiptDate   string // 受付年月日
+	ReceiptNumber string // 受付番号
+	Cause         string // 原因
+	RightHolders  []RightHolder
+	Details       string // 権利者その他の事項
+	Struck        bool   // 後の登記で抹消されていればtrue
+}
+
+// Fudosan は不動産登記の全部事項証明書
+type Fudosan struct {
+	Content  string
+	Type     PropertyType
+	Hyodaibu Hyodaibu
+	Kouku    []Entry // 権利部（甲区）: 所有権に関する事項
+	Otsuku   []Entry // 権利部（乙区）: 所有権以外の権利に関する事項
+}
+
+func (e Entry) String() string {
+	var names []string
+	for _, h := range e.RightHolders {
+		names = append(names, h.Role+" "+h.Name)
+	}
+	s := fmt.Sprintf("%s %s %s%s %s", e.Rank, e.Purpose, e.ReceiptDate, e.ReceiptNumber, strings.Join(names, "、"))
+	if e.Struck {
+		s += "（抹消）"
+	}
+	return s
+}
+
+func (f *Fudosan) String() string {
+	h := f.Hyodaibu
+	s := fmt.Sprintf("種別: %s\n不動産番号: %s\n所在: %s\n", f.Type, h.FudosanBangou, h.Shozai)
+	switch f.Type {
+	case PropertyLand:
+		s += fmt.Sprintf("地番: %s\n地目: %s\n地積: %s\n", h.Chiban, h.Chimoku, h.Chiseki)
+	case PropertyBuilding:
+		s += fmt.Sprintf("家屋番号: %s\n種類: %s\n構造: %s\n床面積: %s\n", h.KaokuBangou, h.Shurui, h.Kouzou, strings.Join(h.Yukamenseki, " "))
+	}
+	for _, e := range f.Kouku {
+		s += fmt.Sprintf("甲区: %s\n", e)
+	}
+	for _, e := range f.Otsuku {
+		s += fmt.Sprintf("乙区: %s\n", e)
+	}
+	return s
+}
+
+// Owners は現在の所有者を返す
+func (f *Fudosan) Owners() []RightHolder {
+	for i := len(f.Kouku) - 1; i >= 0; i-- {
+		e := f.Kouku[i]
+		if e.Struck || !strings.HasPrefix(e.Purpose, "所有権") || strings.Contains(e.Purpose, "抹消") {
+			continue
+		}
+		var owners []RightHolder
+		for _, h := range e.RightHolders {
+			if h.Role == "所有者" || h.Role == "共有者" {
+				owners = append(owners, h)
+			}
+		}
+		if len(owners) > 0 {
+			return owners
+		}
+	}
+	return nil
+}
+
+// Mortgages は抹消されていない抵当権・根抵当権の登記を返す
+func (f *Fudosan) Mortgages() []Entry {
+	var mortgages []Entry
+	for _, e := range f.Otsuku {
+		if !e.Struck && strings.Contains(e.Purpose, "抵当権設定") {
+			mortgages = append(mortgages, e)
+		}
+	}
+	return mortgages
+}
@@ -0,0 +1,320 @@
+package fudosan
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"vandal/toukibo"
+)
+
+type part int
+
+const (
+	partNone part = iota
+	partHyodaibu
+	partKouku
+	partOtsuku
+	partOther // 附属建物、共同担保目録など読み取らない表
+)
+
+const yohaku = "余白"
+
+// 権利者その他の事項の各項目の先頭に書かれる語句。長いものから順に照合する
+var itemKeys = []string{
+	"根抵当権者", "抵当権者", "地上権者", "賃借権者", "地役権者", "質権者", "共有者", "所有者", "権利者", "債務者",
+	"債権の範囲", "債権額", "極度額", "損害金", "利息", "原因", "共同担保", "存続期間", "地代", "賃料",
+	"特約", "目的", "取扱店", "代位者", "代位原因",
+}
+
+// 権利者として読み取る項目
+var rightHolderRoles = map[string]bool{
+	"根抵当権者": true, "抵当権者": true, "地上権者": true, "賃借権者": true, "地役権者": true,
+	"質権者": true, "共有者": true, "所有者": true, "権利者": true, "債務者": true,
+}
+
+var (
+	receiptNumberRegex = regexp.MustCompile(`第[０-９0-9]+号`)
+	strikeRegex        = regexp.MustCompile(`^((?:[０-９0-9]+番(?:付記[０-９0-9]+号)?[、，]?)+).*抹消$`)
+	strikeRankRegex    = regexp.MustCompile(`[０-９0-9]+番(?:付記[０-９0-9]+号)?`)
+	shareRegex         = regexp.MustCompile(`(?:持分)?[０-９0-9]+分の[０-９0-9]+`)
+	floorRegex         = regexp.MustCompile(`^([０-９0-9]+階|地下[０-９0-9]+階)[　 ]*(.+)$`)
+	circledNumberRegex = regexp.MustCompile(`[①-⑳]`)
+)
+
+func squeeze(s string) string {
+	return strings.NewReplacer("　", "", " ", "", "│", "").Replace(s)
+}
+
+// normalizeArea は「１２３：４５」のような面積を「123.45」にする
+func normalizeArea(s string) string {
+	return strings.ReplaceAll(toukibo.ZenkakuToHankaku(squeeze(s)), ":", ".")
+}
+
+// Parse は不動産登記の全部事項証明書の内容を読み取る
+func Parse(content string) (*Fudosan, error) {
+	f := &Fudosan{Content: content, Type: PropertyUnknown}
+
+	var (
+		current  = partNone
+		columns  []string // 表題部の見出し
+		label    string   // 表題部の「所在」「家屋番号」などの行で最後に見た項目名
+		records  [][]string
+		entries  *[]Entry
+		rankSeen bool
+		ruled    bool // 直前の行が権利者その他の事項の欄の罫線
+	)
+	var rows []toukibo.Row
+	for _, part := range toukibo.SplitParts(content) {
+		rows = append(rows, toukibo.SplitRows(part)...)
+	}
+	for _, r := range rows {
+		if r.IsBlank() {
+			// 罫線だけの行。権利者その他の事項の欄に引かれていれば、次の行から別の項目になる
+			ruled = ruled || strings.Contains(r.Value, "├")
+			continue
+		}
+		cells := r.Cells()
+		title := squeeze(r.Label + r.Value)
+		switch {
+		case strings.Contains(title, "表題部"):
+			current = partHyodaibu
+			columns, label = nil, ""
+			switch {
+			case strings.Contains(title, "土地の表示"):
+				f.Type = PropertyLand
+			case strings.Contains(title, "附属建物"):
+				current = partOther
+			case strings.Contains(title, "建物の表示") || strings.Contains(title, "主である建物"):
+				f.Type = PropertyBuilding
+			}
+			for i, c := range cells {
+				if squeeze(c) == "不動産番号" && i+1 < len(cells) {
+					f.Hyodaibu.FudosanBangou = toukibo.ZenkakuToHankaku(squeeze(cells[i+1]))
+				}
+			}
+			continue
+		case strings.Contains(title, "権利部（甲区）"):
+			current, entries, rankSeen = partKouku, &f.Kouku, false
+			continue
+		case strings.Contains(title, "権利部（乙区）"):
+			current, entries, rankSeen = partOtsuku, &f.Otsuku, false
+			continue
+		case strings.Contains(title, "共同担保目録") || strings.Contains(title, "信託目録"):
+			current = partOther
+			continue
+		}
+		if len(cells) < 2 {
+			// 表の外の頁見出しなど
+			continue
+		}
+
+		switch current {
+		case partHyodaibu:
+			first := circledNumberRegex.ReplaceAllString(squeeze(cells[0]), "")
+			switch {
+			case first == "所在" || first == "家屋番号" || first == "地図番号" || first == "所在図番号" || first == "建物の名称":
+				label, columns = first, nil
+				f.Hyodaibu.setLabel(label, cells[1], false)
+			case first == "" && columns == nil && label != "":
+				// 右の列が空であれば前の行の続き、そうでなければ変更後の値
+				f.Hyodaibu.setLabel(label, cells[1], len(cells) < 3 || squeeze(cells[2]) == "")
+			case strings.HasPrefix(first, "地番") || strings.HasPrefix(first, "種類") || strings.HasPrefix(first, "符号"):
+				label, records = "", nil
+				columns = columns[:0]
+				for _, c := range cells {
+					columns = append(columns, circledNumberRegex.ReplaceAllString(squeeze(c), ""))
+				}
+				if strings.HasPrefix(first, "符号") {
+					// 附属建物の表は読み取らない
+					columns = []string{}
+				}
+			case len(columns) > 0:
+				if first != "" || len(records) == 0 {
+					records = append(records, make([]string, len(columns)))
+				}
+				rec := records[len(records)-1]
+				for i, c := range cells {
+					if i >= len(rec) || c == "" {
+						continue
+					}
+					if rec[i] != "" {
+						rec[i] += "\n"
+					}
+					rec[i] += c
+				}
+				f.Hyodaibu.setColumns(columns, rec)
+			}
+		case partKouku, partOtsuku:
+			if squeeze(cells[0]) == "順位番号" {
+				rankSeen = true
+				continue
+			}
+			if !rankSeen || len(cells) < 4 {
+				continue
+			}
+			if rank := squeeze(cells[0]); rank != "" || len(*entries) == 0 {
+				*entries = append(*entries, Entry{Rank: toukibo.ZenkakuToHankaku(rank)})
+			}
+			e := &(*entries)[len(*entries)-1]
+			e.Purpose += squeeze(cells[1])
+			e.Details = joinDetail(e.Details, cells[3], ruled)
+			ruled = false
+			receipt := e.ReceiptDate + e.ReceiptNumber + squeeze(cells[2])
+			if d := toukibo.WarekiRegex.FindString(receipt); d != "" {
+				e.ReceiptDate = toukibo.NormalizeDate(d)
+			}
+			if n := receiptNumberRegex.FindString(receipt); n != "" {
+				e.ReceiptNumber = toukibo.ZenkakuToHankaku(n)
+			}
+		}
+	}
+
+	if f.Type == PropertyUnknown {
+		return f, fmt.Errorf("表題部が見つかりませんでした")
+	}
+	finishEntries(f.Kouku)
+	finishEntries(f.Otsuku)
+	return f, nil
+}
+
+// joinDetail は権利者その他の事項を、項目ごとに改行で区切って連結する。
+// newItem が true であれば line は項目名がなくても新しい項目になる
+func joinDetail(details, line string, newItem bool) string {
+	line = strings.Trim(line, "　 ")
+	if line == "" || line == yohaku {
+		return details
+	}
+	if details == "" {
+		return line
+	}
+	if newItem || itemKey(line) != "" {
+		return details + "\n" + line
+	}
+	return details + "　" + line
+}
+
+func (h *Hyodaibu) setLabel(label, value string, cont bool) {
+	value = strings.Trim(value, "　 ")
+	if squeeze(value) == yohaku {
+		return
+	}
+	var field *string
+	switch label {
+	case "所在":
+		field = &h.Shozai
+	case "家屋番号":
+		field = &h.KaokuBangou
+	default:
+		return
+	}
+	value = toukibo.ZenkakuToHankaku(value)
+	if cont {
+		*field += value
+	} else if value != "" {
+		*field = value
+	}
+}
+
+// setColumns は表題部の1回分の登記のうち余白でない列で現在の値を置き換える
+func (h *Hyodaibu) setColumns(columns []string, rec []string) {
+	for i, col := range columns {
+		v := strings.Trim(rec[i], "　 \n")
+		if v == "" || squeeze(v) == yohaku {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(col, "地番"):
+			h.Chiban = toukibo.ZenkakuToHankaku(squeeze(v))
+		case strings.HasPrefix(col, "地目"):
+			h.Chimoku = squeeze(v)
+		case strings.HasPrefix(col, "地積"):
+			h.Chiseki = normalizeArea(v)
+		case strings.HasPrefix(col, "種類"):
+			h.Shurui = squeeze(v)
+		case strings.HasPrefix(col, "構造"):
+			h.Kouzou = toukibo.ZenkakuToHankaku(squeeze(v))
+		case strings.HasPrefix(col, "床面積"):
+			h.Yukamenseki = nil
+			for _, line := range strings.Split(v, "\n") {
+				line = strings.Trim(line, "　 ")
+				if m := floorRegex.FindStringSubmatch(line); m != nil {
+					h.Yukamenseki = append(h.Yukamenseki, toukibo.ZenkakuToHankaku(m[1])+" "+normalizeArea(m[2]))
+				} else if line != "" {
+					h.Yukamenseki = append(h.Yukamenseki, normalizeArea(line))
+				}
+			}
+		}
+	}
+}
+
+// finishEntries は原因と権利者を読み取り、後の登記で抹消された登記に印をつける
+func finishEntries(entries []Entry) {
+	struck := make(map[string]bool)
+	for i := range entries {
+		e := &entries[i]
+		for _, item := range strings.Split(e.Details, "\n") {
+			key := itemKey(item)
+			value := strings.Trim(strings.TrimPrefix(item, key), "　 ")
+			switch {
+			case key == "原因":
+				e.Cause = toukibo.NormalizeDate(value)
+			case rightHolderRoles[key]:
+				e.RightHolders = append(e.RightHolders, parseRightHolders(key, value)...)
+			}
+		}
+		if m := strikeRegex.FindStringSubmatch(e.Purpose); m != nil {
+			for _, rank := range strikeRankRegex.FindAllString(m[1], -1) {
+				struck[toukibo.ZenkakuToHankaku(strings.Replace(rank, "番", "", 1))] = true
+			}
+		}
+	}
+	for i := range entries {
+		if struck[entries[i].Rank] {
+			entries[i].Struck = true
+		}
+	}
+}
+
+func itemKey(item string) string {
+	for _, key := range itemKeys {
+		if strings.HasPrefix(item, key) {
+			return key
+		}
+	}
+	return ""
+}
+
+// parseRightHolders は「住所　氏名」の並びを読み取る。共有者のように複数の権利者が
+// 続く場合は住所の書き出しで分ける
+func parseRightHolders(role, s string) []RightHolder {
+	var chunks []string
+	locs := toukibo.PrefectureRegex.FindAllStringIndex(s, -1)
+	if len(locs) <= 1 {
+		chunks = []string{s}
+	} else {
+		prev := 0
+		for _, loc := range locs[1:] {
+			chunks = append(chunks, s[prev:loc[0]])
+			prev = loc[0]
+		}
+		chunks = append(chunks, s[prev:])
+	}
+
+	var holders []RightHolder
+	for _, chunk := range chunks {
+		h := RightHolder{Role: role}
+		if share := shareRegex.FindString(chunk); share != "" {
+			h.Share = toukibo.ZenkakuToHankaku(share)
+			chunk = strings.Replace(chunk, share, "", 1)
+		}
+		address, name := toukibo.SplitAddressName(strings.Trim(chunk, "　 "))
+		h.Address = toukibo.ZenkakuToHankaku(strings.Trim(address, "　 "))
+		h.Name = strings.Trim(name, "　 ")
+		if h.Name == "" && h.Address == "" {
+			continue
+		}
+		holders = append(holders, h)
+	}
+	return holders
+}
@@ -0,0 +1,292 @@
+// Package jusho は本店や役員の住所を都道府県、市区町村、町域、番地、建物に分ける。
+// 市区町村は埋め込みの全市区町村の一覧から判断するので、ネットワークを使わない。
+// 一覧にない古い市区町村だけを末尾の文字で区切る。
+package jusho
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"vandal/normalize"
+)
+
+// Address は分割した住所。Raw に元の文字列を残す
+type Address struct {
+	Raw        string
+	Prefecture string // 都道府県
+	County     string // 郡
+	City       string // 市区町村。東京都の特別区を含む
+	Ward       string // 政令指定都市の区
+	Town       string // 町域（大字、字を含む）
+	Chome      string // 丁目。算用数字
+	Banchi     string // 番地または番。算用数字
+	Go         string // 号。算用数字
+	Building   string // 建物名、部屋番号など
+}
+
+type municipality struct {
+	prefecture string
+	county     string
+	city       string
+	wards      []string // 長いものから順に並べる
+}
+
+// municipalityName は住所の先頭と照合する市区町村の書き方。
+// 郡に属する町村は「余市郡余市町」と、郡を省いた「余市町」の両方を載せる
+type municipalityName struct {
+	name string
+	m    *municipality
+}
+
+//go:embed municipalities.tsv
+var municipalitiesTSV string
+
+var prefectures = []string{
+	"北海道", "青森県", "岩手県", "宮城県", "秋田県", "山形県", "福島県",
+	"茨城県", "栃木県", "群馬県", "埼玉県", "千葉県", "東京都", "神奈川県",
+	"新潟県", "富山県", "石川県", "福井県", "山梨県", "長野県", "岐阜県",
+	"静岡県", "愛知県", "三重県", "滋賀県", "京都府", "大阪府", "兵庫県",
+	"奈良県", "和歌山県", "鳥取県", "島根県", "岡山県", "広島県", "山口県",
+	"徳島県", "香川県", "愛媛県", "高知県", "福岡県", "佐賀県", "長崎県",
+	"熊本県", "大分県", "宮崎県", "鹿児島県", "沖縄県",
+}
+
+// PrefectureCode は都道府県の全国地方公共団体コードの上2桁を返す。都道府県でなければ空を返す
+func PrefectureCode(name string) string {
+	for i, p := range prefectures {
+		if p == name {
+			return fmt.Sprintf("%02d", i+1)
+		}
+	}
+	return ""
+}
+
+var municipalities = func() []municipality {
+	var list []municipality
+	for _, line := range strings.Split(municipalitiesTSV, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Split(line, "\t")
+		m := municipality{prefecture: f[0], county: f[1], city: f[2]}
+		if len(f) > 3 {
+			m.wards = strings.Fields(f[3])
+			sortLongestFirst(m.wards, func(i int) string { return m.wards[i] })
+		}
+		list = append(list, m)
+	}
+	return list
+}()
+
+// municipalityNames は長い名前から照合できるように並べた市区町村の書き方。
+// 「蒲郡市」を「蒲郡」と「市」に、「西村山郡」を「西村」と「山郡」に分けないため
+var municipalityNames = func() []municipalityName {
+	var names []municipalityName
+	for i := range municipalities {
+		m := &municipalities[i]
+		names = append(names, municipalityName{m.city, m})
+		if m.county != "" {
+			names = append(names, municipalityName{m.county + m.city, m})
+		}
+	}
+	sortLongestFirst(names, func(i int) string { return names[i].name })
+	return names
+}()
+
+// counties は一覧にない町村の住所で郡を見分けるための、都道府県と郡の組
+var counties = func() []municipality {
+	var list []municipality
+	seen := make(map[string]bool)
+	for _, m := range municipalities {
+		key := m.prefecture + m.county
+		if m.county != "" && !seen[key] {
+			seen[key] = true
+			list = append(list, municipality{prefecture: m.prefecture, county: m.county})
+		}
+	}
+	sortLongestFirst(list, func(i int) string { return list[i].county })
+	return list
+}()
+
+// sortLongestFirst は長い名前が先に、同じ長さなら辞書順に並ぶように list を並べ替える
+func sortLongestFirst(list any, name func(i int) string) {
+	sort.SliceStable(list, func(i, j int) bool {
+		ni, nj := name(i), name(j)
+		if len(ni) != len(nj) {
+			return len(ni) > len(nj)
+		}
+		return ni < nj
+	})
+}
+
+var (
+	countyRegex = regexp.MustCompile(`^[^市区郡]{1,5}郡`)
+	chomeRegex  = regexp.MustCompile(`^(.*?)([0-9]+|[〇一二三四五六七八九十]+)丁目`)
+	// 番地の書き出し。「三番町」のような町名は番地としない
+	banchiStartRegex = regexp.MustCompile(`[0-9]|[〇一二三四五六七八九十百千]+(?:番地|番[^町]|番$|号)`)
+	// 「22番地2」「22番2号」「22-2」「22の2」「1-2-3」など
+	numberRegex = regexp.MustCompile(`^([0-9]+)(?:番地の?|番の?|の|-)?(?:([0-9]+)(?:号|番地の?|番の?|の|-)?)?(?:([0-9]+)号?)?`)
+	// 番地と号の部分。区切りの横線は「ー」「−」などで書かれることがある
+	numberPartRegex = regexp.MustCompile(`^[0-9]+(?:(?:番地の?|番の?|の|[-‐−ー―])[0-9]+)*`)
+	dashReplacer    = strings.NewReplacer("‐", "-", "−", "-", "ー", "-", "―", "-")
+)
+
+// hasPrefix は「ケ」と「ヶ」の違いを無視して s が name で始まるか調べる
+func hasPrefix(s, name string) bool {
+	return strings.HasPrefix(s, name) || strings.HasPrefix(s, strings.ReplaceAll(name, "ケ", "ヶ"))
+}
+
+// splitBySuffix は s の先頭から、2文字目以降で最初に現れる suffixes のいずれかまでを返す。
+// 「廿日市市」のように区切りの文字の直後に市区町村郡が続くときは、そこでは区切らない
+func splitBySuffix(s, suffixes string) (string, string) {
+	for i, r := range s {
+		if i == 0 || !strings.ContainsRune(suffixes, r) {
+			continue
+		}
+		end := i + utf8.RuneLen(r)
+		if next, _ := utf8.DecodeRuneInString(s[end:]); strings.ContainsRune("市区町村郡", next) {
+			continue
+		}
+		return s[:end], s[end:]
+	}
+	return "", s
+}
+
+// lookupMunicipality は s の先頭に書かれた市区町村を一覧から長い名前を優先して探し、
+// 照合した書き方を返す。pref が空で同じ書き方の市区町村が他の都道府県にもあれば全て返す
+func lookupMunicipality(pref, s string) (string, []*municipality) {
+	var found []*municipality
+	name := ""
+	for _, n := range municipalityNames {
+		if name != "" {
+			if n.name != name {
+				break
+			}
+		} else if !hasPrefix(s, n.name) {
+			continue
+		}
+		if pref != "" && n.m.prefecture != pref {
+			continue
+		}
+		name = n.name
+		found = append(found, n.m)
+	}
+	return name, found
+}
+
+// Parse は住所を分割する。読み取れなかった部分は Town または Building に残す
+func Parse(raw string) Address {
+	a := Address{Raw: raw}
+	s := strings.Trim(normalize.Width(raw), " ")
+
+	for _, p := range prefectures {
+		if strings.HasPrefix(s, p) {
+			a.Prefecture = p
+			s = s[len(p):]
+			break
+		}
+	}
+
+	s = a.parseMunicipality(s)
+	s = a.parseTown(s)
+	a.Building = strings.Trim(s, " ,、")
+	return a
+}
+
+func (a *Address) parseMunicipality(s string) string {
+	if name, found := lookupMunicipality(a.Prefecture, s); found != nil {
+		m := found[0]
+		rest := s[len(name):]
+		a.City = m.city
+		if len(found) == 1 {
+			a.Prefecture, a.County = m.prefecture, m.county
+		} else if len(name) > len(m.city) {
+			// 郡から書かれていれば、都道府県が分からなくても郡は分かる
+			a.County = m.county
+		}
+		for _, w := range m.wards {
+			if hasPrefix(rest, w) {
+				a.Ward = w
+				return rest[len(w):]
+			}
+		}
+		if len(m.wards) > 0 {
+			a.Ward, rest = splitBySuffix(rest, "区")
+		}
+		return rest
+	}
+
+	// 一覧にないのは、合併でなくなった市区町村などの古い住所。
+	// 郡が一覧にあればそれを使い、なければ末尾の文字で区切る
+	for _, c := range counties {
+		if (a.Prefecture == "" || c.prefecture == a.Prefecture) && hasPrefix(s, c.county) {
+			a.County = c.county
+			a.City, s = splitBySuffix(s[len(c.county):], "町村")
+			return s
+		}
+	}
+	if c := countyRegex.FindString(s); c != "" {
+		// 「蒲郡市」「郡山市」の郡は区切りではない
+		if next, _ := utf8.DecodeRuneInString(s[len(c):]); !strings.ContainsRune("市区町村郡", next) {
+			a.County = c
+			a.City, s = splitBySuffix(s[len(c):], "町村")
+			return s
+		}
+	}
+	a.City, s = splitBySuffix(s, "市区町村")
+	return s
+}
+
+func (a *Address) parseTown(s string) string {
+	if m := chomeRegex.FindStringSubmatch(s); m != nil && !strings.ContainsAny(m[1], "0123456789") {
+		a.Town = m[1]
+		a.Chome = normalize.String(m[2]+"丁目", normalize.Options{KanjiNumerals: true})
+		a.Chome = strings.TrimSuffix(a.Chome, "丁目")
+		s = s[len(m[0]):]
+	} else {
+		loc := banchiStartRegex.FindStringIndex(s)
+		if loc == nil {
+			a.Town = strings.Trim(s, " ")
+			return ""
+		}
+		a.Town, s = s[:loc[0]], s[loc[0]:]
+	}
+	a.Town = strings.Trim(a.Town, " ")
+
+	// 番地の漢数字を算用数字にする
+	s = normalize.String(s, normalize.Options{KanjiNumerals: true})
+	// 横線をそろえるのは番地の数字の間だけにする。建物名の「ー」は長音なので残す
+	s = strings.TrimLeft(s, " ")
+	if n := len(numberPartRegex.FindString(s)); n > 0 {
+		s = dashReplacer.Replace(s[:n]) + s[n:]
+	}
+	m := numberRegex.FindStringSubmatch(s)
+	if m == nil {
+		return s
+	}
+	nums := []string{m[1], m[2], m[3]}
+	if a.Chome == "" && m[3] != "" {
+		// 「4-22-2」のように丁目を数字だけで書いたもの
+		a.Chome, nums = nums[0], nums[1:]
+	}
+	a.Banchi = nums[0]
+	if len(nums) > 1 {
+		a.Go = nums[1]
+	}
+	return s[len(m[0]):]
+}
+
+// String は元の住所を返す
+func (a Address) String() string {
+	return a.Raw
+}
+
+// Key は同じ住所を見分けるための鍵を返す。表記揺れ、異体字、建物名の違いは無視する
+func (a Address) Key() string {
+	numbers := strings.Join([]string{a.Chome, a.Banchi, a.Go}, "-")
+	return normalize.MatchKey(a.Prefecture+a.County+a.City+a.Ward+a.Town) + "|" + numbers
+}
@@ -4,19 +4,38 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"os"
+	"vandal/fudosan"
+	"vandal/nta"
 	"vandal/pdf"
 	"vandal/toukibo"
 )
 
 func main() {
 	f := flag.String("path", "sample1", "")
+	g := flag.String("gaiji", "", "外字の対応表")
+	n := flag.String("nta", "", "照合に使う法人番号公表サイトの全件データ（CSV）")
+	c := flag.Bool("csv", false, "法人番号公表サイトの全件データと同じ列の並びで出力する")
+	fd := flag.Bool("fudosan", false, "不動産登記の全部事項証明書（sample/fudosan）として読み取る")
 	flag.Parse()
-	path := fmt.Sprintf("sample/houjin/%s.pdf", *f)
-	content, err := readPdf(path)
+	dir := "houjin"
+	if *fd {
+		dir = "fudosan"
+	}
+	path := fmt.Sprintf("sample/%s/%s.pdf", dir, *f)
+	content, err := readPdf(path, *g)
 
 	if err != nil {
 		panic(err)
 	}
+	if *fd {
+		property, err := fudosan.Parse(content)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(property.String())
+		return
+	}
 	//_, err = toukibo.Extract(content)
 	tc, err := toukibo.Parse(content)
 	if err != nil {
@@ -24,16 +43,49 @@ func main() {
 	}
 	houjin := toukibo.NewHoujinFromToukibo(tc)
 	houjin.Extract()
+	if *n != "" {
+		ix := nta.NewIndex()
+		if err := ix.ImportFiles(*n); err != nil {
+			panic(err)
+		}
+		ix.Check(houjin)
+	}
 
+	if *c {
+		w := nta.NewWriter(os.Stdout)
+		if err := w.Write(houjin); err != nil {
+			panic(err)
+		}
+		if err := w.Flush(); err != nil {
+			panic(err)
+		}
+		return
+	}
 	fmt.Println(houjin.String())
 	return
 }
 
-func readPdf(path string) (string, error) {
+func readPdf(path, gaijiPath string) (string, error) {
 	r, err := pdf.Open(path)
 	if err != nil {
 		return "", err
 	}
+	defer r.Close()
+	if err := r.Repaired(); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 相互参照表が壊れていたため再構築しました: %v\n", err)
+	}
+	if gaijiPath != "" {
+		gf, err := os.Open(gaijiPath)
+		if err != nil {
+			return "", err
+		}
+		defer gf.Close()
+		m, err := pdf.ReadGaijiMap(gf)
+		if err != nil {
+			return "", err
+		}
+		r.SetGaijiMap(m)
+	}
 	var buf bytes.Buffer
 	b, err := r.GetPlainText()
 	if err != nil {
@@ -0,0 +1,61 @@
+package normalize
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// 数字の間に置かれるハイフンとして扱う文字
+const dashes = "-‐‑‒–—―−－ーｰ"
+
+// 数字の後に続く住所の区切り。長いものから順に照合する
+var addressUnits = []string{"丁目", "番地の", "番地", "番の", "番", "号", "の"}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// foldAddress は「4丁目22番2号」「1番地の2」「1ー2」を「4-22-2」「1-2」「1-2」にする。
+// 後に数字が続かない丁目はそのまま残す
+func foldAddress(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		if !isDigit(s[i]) {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			b.WriteRune(r)
+			i += size
+			continue
+		}
+		j := i
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+		b.WriteString(s[i:j])
+		i = j
+
+		unit := ""
+		if r, size := utf8.DecodeRuneInString(s[i:]); r != utf8.RuneError && strings.ContainsRune(dashes, r) {
+			unit = s[i : i+size]
+		} else {
+			for _, u := range addressUnits {
+				if strings.HasPrefix(s[i:], u) {
+					unit = u
+					break
+				}
+			}
+		}
+		if unit == "" {
+			continue
+		}
+		next := i + len(unit)
+		switch {
+		case next < len(s) && isDigit(s[next]):
+			b.WriteByte('-')
+			i = next
+		case unit == "番地" || unit == "番" || unit == "号":
+			i = next
+		}
+	}
+	return b.String()
+}
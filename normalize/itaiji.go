@@ -0,0 +1,65 @@
+package normalize
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 異体字・旧字体から照合に使う字体への対応。人名や商号によく現れるものに限る
+var itaiji = map[rune]rune{
+	'髙': '高', '\uFA11': '崎', '嵜': '崎', '碕': '崎', '邊': '辺', '邉': '辺', '齋': '斎', '齊': '斉',
+	'澤': '沢', '濱': '浜', '濵': '浜', '廣': '広', '國': '国', '櫻': '桜', '眞': '真', '藏': '蔵',
+	'會': '会', '學': '学', '團': '団', '體': '体', '實': '実', '來': '来', '與': '与', '壽': '寿',
+	'榮': '栄', '惠': '恵', '德': '徳', '龍': '竜', '嶋': '島', '嶌': '島', '嶽': '岳', '峯': '峰',
+	'冨': '富', '淵': '渕', '槇': '槙', '桒': '桑', '舘': '館', '萬': '万', '亞': '亜', '圓': '円',
+	'瀨': '瀬', '靜': '静', '靑': '青', '黑': '黒', '鐵': '鉄', '驛': '駅', '寶': '宝', '戶': '戸',
+	'將': '将', '條': '条', '數': '数', '淺': '浅', '兒': '児', '縣': '県', '莊': '荘', '臺': '台',
+	'灣': '湾', '彌': '弥', '禮': '礼', '藝': '芸', '爲': '為', '乘': '乗', '螢': '蛍', '圖': '図',
+	'傳': '伝', '燈': '灯', '聰': '聡', '獨': '独', '辯': '弁', '辨': '弁', '瓣': '弁', '讀': '読',
+	'賣': '売', '麥': '麦', '豐': '豊', '禪': '禅', '佛': '仏', '檜': '桧', '樂': '楽', '醫': '医',
+	'鹽': '塩', '營': '営', '變': '変', '彈': '弾', '舊': '旧', '勞': '労', '險': '険', '顯': '顕',
+	'廳': '庁', '證': '証', '齒': '歯', '總': '総', '髮': '髪', '增': '増', '稻': '稲', '曾': '曽',
+	'橫': '横', '關': '関', '溪': '渓', '淨': '浄', '狹': '狭', '萠': '萌', '巖': '巌', '昻': '昂',
+	'逹': '達', '穗': '穂', '薰': '薫', '檢': '検', '卷': '巻', '氣': '気', '參': '参', '恆': '恒',
+	'拔': '抜', '搜': '捜', '祕': '秘', '淸': '清', '晉': '晋',
+	// CJK互換漢字
+	'\uFA10': '塚', '\uFA12': '晴', '\uFA15': '凞', '\uFA19': '神', '\uFA1A': '祥', '\uFA1B': '福',
+	'\uFA1C': '靖', '\uFA1D': '精', '\uFA1E': '羽', '\uFA22': '諸', '\uFA26': '都', '\uFA2A': '飯',
+	// 小書きの「ケ」「カ」は地名や商号で「ケ」「カ」と同じに扱う
+	'ヶ': 'ケ', 'ヵ': 'カ',
+}
+
+// isVariationSelector は異体字セレクタ（IVSの後半）であればtrueを返す
+func isVariationSelector(r rune) bool {
+	return r >= 0xFE00 && r <= 0xFE0F || r >= 0xE0100 && r <= 0xE01EF
+}
+
+// MatchKey は商号や氏名を照合するための鍵を返す。幅をそろえ、異体字・旧字体を
+// 対応する字体に置き換え、異体字セレクタと空白を取り除き、英字を大文字にする。
+// 登記された表記は書き換えないので、表示には元の文字列を使う
+func MatchKey(s string) string {
+	s = foldWidth(s, KanaFold)
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if isVariationSelector(r) || unicode.IsSpace(r) {
+			continue
+		}
+		if v, ok := itaiji[r]; ok {
+			r = v
+		}
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SameName は2つの商号や氏名が異体字の違いを除いて同じであればtrueを返す
+func SameName(a, b string) bool {
+	return MatchKey(a) == MatchKey(b)
+}
@@ -0,0 +1,102 @@
+package normalize
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var kanjiDigits = map[rune]int64{
+	'〇': 0, '零': 0, '一': 1, '壱': 1, '二': 2, '弐': 2, '三': 3, '参': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+var kanjiUnits = map[rune]int64{'十': 10, '拾': 10, '百': 100, '千': 1000}
+
+var kanjiLargeUnits = map[rune]int64{'万': 10000, '億': 100000000, '兆': 1000000000000}
+
+// 漢数字の後に続くときだけ数字に直す語句。住所の番号と金額、株数に限る。
+// 「十日町」「四日市」「三条」「二階堂」のような地名を数字にしないため、日、条、階などは含めない
+var counterSuffixes = []string{
+	"丁目", "番地", "番", "号", "地割", "円", "株", "口", "個",
+}
+
+// 番の後に続くと「一番町」「二番丁」のような町名になる文字
+const townAfterBan = "町丁"
+
+func isKanjiNumeral(r rune) bool {
+	_, d := kanjiDigits[r]
+	_, u := kanjiUnits[r]
+	_, l := kanjiLargeUnits[r]
+	return d || u || l
+}
+
+func hasCounterSuffix(s string) bool {
+	for _, suffix := range counterSuffixes {
+		if !strings.HasPrefix(s, suffix) {
+			continue
+		}
+		if suffix == "番" {
+			r, _ := utf8.DecodeRuneInString(s[len(suffix):])
+			return !strings.ContainsRune(townAfterBan, r)
+		}
+		return true
+	}
+	return false
+}
+
+// kanjiToInt は「一〇〇」「三百五十」「十二」「二〇万」のような漢数字を数にする
+func kanjiToInt(s string) (int64, bool) {
+	var total, section, current int64
+	digits := 0
+	for _, r := range s {
+		if d, ok := kanjiDigits[r]; ok {
+			// 「一〇〇」のような位取りの書き方
+			current = current*10 + d
+			digits++
+			continue
+		}
+		if u, ok := kanjiUnits[r]; ok {
+			if digits == 0 {
+				current = 1
+			}
+			section += current * u
+		} else if u, ok := kanjiLargeUnits[r]; ok {
+			if digits == 0 && section == 0 {
+				return 0, false
+			}
+			total += (section + current) * u
+			section = 0
+		}
+		current, digits = 0, 0
+	}
+	return total + section + current, true
+}
+
+func foldKanjiNumerals(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !isKanjiNumeral(r) {
+			b.WriteString(s[i : i+size])
+			i += size
+			continue
+		}
+		j := i
+		for j < len(s) {
+			r, size := utf8.DecodeRuneInString(s[j:])
+			if !isKanjiNumeral(r) {
+				break
+			}
+			j += size
+		}
+		if n, ok := kanjiToInt(s[i:j]); ok && hasCounterSuffix(s[j:]) {
+			b.WriteString(strconv.FormatInt(n, 10))
+		} else {
+			b.WriteString(s[i:j])
+		}
+		i = j
+	}
+	return b.String()
+}
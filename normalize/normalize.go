@@ -0,0 +1,120 @@
+// Package normalize は登記簿の文字列を比較や検索に使える形にそろえる。
+// 元の文字列は書き換えないので、表示には元の文字列を使う。
+package normalize
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+type KanaMode int
+
+const (
+	KanaFold KanaMode = iota // 半角カタカナを全角カタカナにする
+	KanaKeep                 // 半角カタカナをそのまま残す
+)
+
+type Options struct {
+	Kana KanaMode
+	// KanjiNumerals は丁目、番地、番、号と金額、株数の単位の直前にある漢数字だけを算用数字にする
+	KanjiNumerals bool
+	// Address は丁目、番地、番、号を「-」でつないだ形にする
+	Address bool
+}
+
+// Text は元の文字列と正規化した文字列の組
+type Text struct {
+	Raw        string
+	Normalized string
+}
+
+func NewText(s string, opt Options) Text {
+	return Text{Raw: s, Normalized: String(s, opt)}
+}
+
+// String は表示用に元の文字列を返す
+func (t Text) String() string {
+	return t.Raw
+}
+
+// Width は全角英数字・記号と全角空白を半角にし、半角カタカナを全角にする
+func Width(s string) string {
+	return String(s, Options{})
+}
+
+// AddressKey は住所を比較するための形にする
+func AddressKey(s string) string {
+	return String(s, Options{KanjiNumerals: true, Address: true})
+}
+
+// String は opt に従って s を正規化する。どの処理も s の長さに比例する時間で終わる
+func String(s string, opt Options) string {
+	s = foldWidth(s, opt.Kana)
+	if opt.KanjiNumerals {
+		s = foldKanjiNumerals(s)
+	}
+	if opt.Address {
+		s = foldAddress(s)
+	}
+	return s
+}
+
+func foldWidth(s string, kana KanaMode) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r >= '！' && r <= '～':
+			b.WriteRune(r - '！' + '!')
+		case r == '　':
+			b.WriteByte(' ')
+		case r == '￥':
+			b.WriteRune('¥')
+		case kana == KanaFold && r >= 0xFF61 && r <= 0xFF9F:
+			z, ok := hankakuKana[r]
+			if !ok {
+				b.WriteRune(r)
+				continue
+			}
+			// 濁点・半濁点が続けば1文字にまとめる
+			if next, nsize := utf8.DecodeRuneInString(s[i:]); next == 'ﾞ' || next == 'ﾟ' {
+				if c, ok := combineMark(z, next); ok {
+					z = c
+					i += nsize
+				}
+			}
+			b.WriteRune(z)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func combineMark(r, mark rune) (rune, bool) {
+	if r == 'ウ' && mark == 'ﾞ' {
+		return 'ヴ', true
+	}
+	if mark == 'ﾞ' && strings.ContainsRune("カキクケコサシスセソタチツテトハヒフヘホ", r) {
+		return r + 1, true
+	}
+	if mark == 'ﾟ' && strings.ContainsRune("ハヒフヘホ", r) {
+		return r + 2, true
+	}
+	return r, false
+}
+
+var hankakuKana = func() map[rune]rune {
+	const (
+		hankaku = "｡｢｣､･ｦｧｨｩｪｫｬｭｮｯｰｱｲｳｴｵｶｷｸｹｺｻｼｽｾｿﾀﾁﾂﾃﾄﾅﾆﾇﾈﾉﾊﾋﾌﾍﾎﾏﾐﾑﾒﾓﾔﾕﾖﾗﾘﾙﾚﾛﾜﾝﾞﾟ"
+		zenkaku = "。「」、・ヲァィゥェォャュョッーアイウエオカキクケコサシスセソタチツテトナニヌネノハヒフヘホマミムメモヤユヨラリルレロワン゛゜"
+	)
+	m := make(map[rune]rune)
+	z := []rune(zenkaku)
+	for i, h := range []rune(hankaku) {
+		m[h] = z[i]
+	}
+	return m
+}()
@@ -0,0 +1,122 @@
+package nta
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"vandal/jusho"
+	"vandal/normalize"
+	"vandal/toukibo"
+)
+
+// Index は法人番号ごとに最新の行を持つ索引
+type Index struct {
+	Records map[string]Record
+}
+
+func NewIndex() *Index {
+	return &Index{Records: make(map[string]Record)}
+}
+
+// Add は rec を索引に加える。同じ法人番号の行があれば、更新年月日と一連番号が新しいほうを残す
+func (ix *Index) Add(rec Record) {
+	old, ok := ix.Records[rec.CorporateNumber]
+	if ok && (old.UpdateDate > rec.UpdateDate || old.UpdateDate == rec.UpdateDate && old.SequenceNumber > rec.SequenceNumber) {
+		return
+	}
+	if rec.Process == ProcessDeleted {
+		delete(ix.Records, rec.CorporateNumber)
+		return
+	}
+	ix.Records[rec.CorporateNumber] = rec
+}
+
+// Import は全件データまたは差分データのCSVを索引に取り込む
+func (ix *Index) Import(r io.Reader) error {
+	return ReadCSV(r, func(rec Record) error {
+		ix.Add(rec)
+		return nil
+	})
+}
+
+// ImportFiles はローカルに保存したCSVファイルを順に取り込む
+func (ix *Index) ImportFiles(paths ...string) error {
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = ix.Import(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Save は索引を書き出す。CSVを読み直すより速く Load で読み込める
+func (ix *Index) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(ix.Records)
+}
+
+// Load は Save で書き出した索引を読み込む
+func Load(r io.Reader) (*Index, error) {
+	ix := NewIndex()
+	if err := gob.NewDecoder(r).Decode(&ix.Records); err != nil {
+		return nil, fmt.Errorf("法人番号の索引を読み込めませんでした: %w", err)
+	}
+	return ix, nil
+}
+
+// Lookup は法人番号に対応する行を返す
+func (ix *Index) Lookup(corporateNumber string) (Record, bool) {
+	rec, ok := ix.Records[corporateNumber]
+	return rec, ok
+}
+
+// Check は h を法人番号公表データと照合し、食い違いを h.Warnings に記録する。
+// 見つかった行を返すので、呼び出し側でフリガナや英語表記を補える
+func (ix *Index) Check(h *toukibo.Houjin) (Record, bool) {
+	warn := func(format string, args ...interface{}) {
+		h.Warnings = append(h.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	number := h.CorporateNumber()
+	if number == "" {
+		warn("会社法人等番号%sから法人番号を計算できませんでした", h.HoujinNumber)
+		return Record{}, false
+	}
+	rec, ok := ix.Lookup(number)
+	if !ok {
+		warn("法人番号%sが法人番号公表データにありません", number)
+		return Record{}, false
+	}
+
+	if !normalize.SameName(rec.Name, h.CompanyName) {
+		warn("商号が法人番号公表データ（%s）と異なります", rec.Name)
+	}
+	if rec.PrefectureName != "" && h.CompanyAddress != "" {
+		if jusho.Parse(rec.Address()).Key() != h.Jusho().Key() {
+			warn("本店が法人番号公表データ（%s）と異なります", rec.Address())
+		}
+	}
+
+	closed := h.Status == toukibo.HoujinStatusClosed
+	switch {
+	case rec.Closed() && !closed:
+		warn("法人番号公表データでは%sに登記記録が閉鎖されています", rec.CloseDate)
+	case !rec.Closed() && closed:
+		warn("登記記録は閉鎖されていますが、法人番号公表データに反映されていません")
+	}
+
+	if !h.CreatedAt.IsZero() {
+		if changed, err := time.ParseInLocation("2006-01-02", rec.ChangeDate, h.CreatedAt.Location()); err == nil && changed.After(h.CreatedAt) {
+			warn("証明書の作成（%s）より後の%sに法人番号公表データが変更されています", h.CreatedAt.Format("2006-01-02"), rec.ChangeDate)
+		}
+	}
+	return rec, true
+}
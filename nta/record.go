@@ -0,0 +1,125 @@
+// Package nta は国税庁の法人番号公表サイトが配布する全件データ（CSV形式・Unicode）を
+// 読み書きし、登記簿から読み取った法人と照合する。
+package nta
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 処理区分
+const (
+	ProcessNew             = "01" // 新規
+	ProcessNameChange      = "11" // 商号又は名称の変更
+	ProcessAddressChange   = "12" // 国内所在地の変更
+	ProcessOverseasChange  = "13" // 国外所在地の変更
+	ProcessClose           = "21" // 登記記録の閉鎖等
+	ProcessRestore         = "22" // 登記記録の復活等
+	ProcessMerger          = "71" // 吸収合併
+	ProcessMergerCancelled = "72" // 吸収合併無効
+	ProcessNameErased      = "81" // 商号の登記の抹消
+	ProcessDeleted         = "99" // 削除
+)
+
+// 登記記録の閉鎖等の事由
+const (
+	CloseCauseLiquidation = "01" // 清算の結了等
+	CloseCauseMerger      = "11" // 合併による解散等
+	CloseCauseRegistrar   = "21" // 登記官による閉鎖
+	CloseCauseOther       = "31" // その他の清算の結了等
+)
+
+// Columns は全件データの列数
+const Columns = 30
+
+// Record は全件データの1行
+type Record struct {
+	SequenceNumber           string // 一連番号
+	CorporateNumber          string // 法人番号
+	Process                  string // 処理区分
+	Correct                  string // 訂正区分
+	UpdateDate               string // 更新年月日
+	ChangeDate               string // 変更年月日
+	Name                     string // 商号又は名称
+	NameImageID              string // 商号又は名称イメージID
+	Kind                     string // 法人種別
+	PrefectureName           string // 国内所在地（都道府県）
+	CityName                 string // 国内所在地（市区町村）
+	StreetNumber             string // 国内所在地（丁目番地等）
+	AddressImageID           string // 国内所在地イメージID
+	PrefectureCode           string // 都道府県コード
+	CityCode                 string // 市区町村コード
+	PostCode                 string // 郵便番号
+	AddressOutside           string // 国外所在地
+	AddressOutsideImageID    string // 国外所在地イメージID
+	CloseDate                string // 登記記録の閉鎖等年月日
+	CloseCause               string // 登記記録の閉鎖等の事由
+	SuccessorCorporateNumber string // 承継先法人番号
+	ChangeCause              string // 変更事由の詳細
+	AssignmentDate           string // 法人番号指定年月日
+	Latest                   string // 最新履歴
+	EnName                   string // 商号又は名称（英語表記）
+	EnPrefectureName         string // 国内所在地（都道府県）（英語表記）
+	EnCityName               string // 国内所在地（市区町村丁目番地等）（英語表記）
+	EnAddressOutside         string // 国外所在地（英語表記）
+	Furigana                 string // フリガナ
+	Hihyoji                  string // 検索対象除外
+}
+
+func (r *Record) fields() []*string {
+	return []*string{
+		&r.SequenceNumber, &r.CorporateNumber, &r.Process, &r.Correct, &r.UpdateDate,
+		&r.ChangeDate, &r.Name, &r.NameImageID, &r.Kind, &r.PrefectureName,
+		&r.CityName, &r.StreetNumber, &r.AddressImageID, &r.PrefectureCode, &r.CityCode,
+		&r.PostCode, &r.AddressOutside, &r.AddressOutsideImageID, &r.CloseDate, &r.CloseCause,
+		&r.SuccessorCorporateNumber, &r.ChangeCause, &r.AssignmentDate, &r.Latest, &r.EnName,
+		&r.EnPrefectureName, &r.EnCityName, &r.EnAddressOutside, &r.Furigana, &r.Hihyoji,
+	}
+}
+
+// Address は国内所在地をつなげて返す
+func (r Record) Address() string {
+	return r.PrefectureName + r.CityName + r.StreetNumber
+}
+
+// Closed は登記記録が閉鎖されていればtrueを返す
+func (r Record) Closed() bool {
+	return r.CloseDate != ""
+}
+
+// Values は全件データの列の順に値を返す
+func (r Record) Values() []string {
+	var values []string
+	for _, f := range r.fields() {
+		values = append(values, *f)
+	}
+	return values
+}
+
+// ReadCSV は全件データを読み込む。Shift_JIS版には対応しないので、Unicode版を使う
+func ReadCSV(r io.Reader, fn func(Record) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = Columns
+	cr.ReuseRecord = true
+	for first := true; ; first = false {
+		values, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("法人番号データを読み込めませんでした: %w", err)
+		}
+		if first {
+			values[0] = strings.TrimPrefix(values[0], "\ufeff")
+		}
+		var rec Record
+		for i, f := range rec.fields() {
+			*f = values[i]
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
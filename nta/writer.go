@@ -0,0 +1,168 @@
+package nta
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"vandal/jusho"
+	"vandal/toukibo"
+)
+
+// 法人種別
+const (
+	KindKabusiki = "301" // 株式会社
+	KindYugen    = "302" // 有限会社
+	KindGoumei   = "303" // 合名会社
+	KindGousi    = "304" // 合資会社
+	KindGoudou   = "305" // 合同会社
+	KindOther    = "399" // その他の設立登記法人
+	KindGaikoku  = "401" // 外国会社等
+	KindUnknown  = "499" // その他
+)
+
+var kindCodes = map[toukibo.HoujinkakuType]string{
+	toukibo.HoujinKakuKabusiki: KindKabusiki,
+	toukibo.HoujinKakuYugen:    KindYugen,
+	toukibo.HoujinKakuGoumei:   KindGoumei,
+	toukibo.HoujinKakuGousi:    KindGousi,
+	toukibo.HoujinKakuGoudou:   KindGoudou,
+	toukibo.HoujinKakuGaikoku:  KindGaikoku,
+	toukibo.HoujinKakuUnknown:  KindUnknown,
+}
+
+// KindCode は法人格に対応する法人種別を返す
+func KindCode(t toukibo.HoujinkakuType) string {
+	if code, ok := kindCodes[t]; ok {
+		return code
+	}
+	return KindOther
+}
+
+var eraStartYears = map[string]int{"明治": 1868, "大正": 1912, "昭和": 1926, "平成": 1989, "令和": 2019}
+
+var normalizedWarekiRegex = regexp.MustCompile(`^(明治|大正|昭和|平成|令和)(元|[0-9]+)年([0-9]+)月([0-9]+)日$`)
+
+// isoDate は「令和5年1月10日」のような和暦を「2023-01-10」にする。読めなければ空を返す
+func isoDate(wareki string) string {
+	m := normalizedWarekiRegex.FindStringSubmatch(toukibo.NormalizeDate(wareki))
+	if m == nil {
+		return ""
+	}
+	year := 1
+	if m[2] != "元" {
+		year, _ = strconv.Atoi(m[2])
+	}
+	month, _ := strconv.Atoi(m[3])
+	day, _ := strconv.Atoi(m[4])
+	return fmt.Sprintf("%04d-%02d-%02d", eraStartYears[m[1]]+year-1, month, day)
+}
+
+func closeCause(k toukibo.ToukiKiroku) string {
+	switch k.Kind {
+	case toukibo.ToukiKirokuSeisanKetsuryo:
+		return CloseCauseLiquidation
+	case toukibo.ToukiKirokuGappei:
+		return CloseCauseMerger
+	case toukibo.ToukiKirokuHeisa:
+		return CloseCauseRegistrar
+	}
+	return CloseCauseOther
+}
+
+// streetNumber は住所の町域、丁目番地、建物名から「蒲田４丁目２２－２」のような丁目番地等の列を作る。
+// 住所を文字列のまま削ると、登記簿と市区町村名の書き方が違うときに市区町村名が残ってしまう
+func streetNumber(a jusho.Address) string {
+	var b strings.Builder
+	b.WriteString(a.Town)
+	if a.Chome != "" {
+		b.WriteString(a.Chome + "丁目")
+	}
+	switch {
+	case a.Go != "":
+		b.WriteString(a.Banchi + "-" + a.Go)
+	case a.Banchi != "":
+		b.WriteString(a.Banchi + "番地")
+	}
+	b.WriteString(a.Building)
+	return toZenkaku(b.String())
+}
+
+// toZenkaku は半角の英数字・記号と空白を全角にする。全件データの住所は全角で書かれている
+func toZenkaku(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return toukibo.ZenkakuSpace
+		case r > ' ' && r <= '~':
+			return r - '!' + '！'
+		}
+		return r
+	}, s)
+}
+
+// NewRecord は登記簿から読み取った法人を全件データの行にする。
+// 登記簿からわからない列（市区町村コード、郵便番号、英語表記など）は空にする
+func NewRecord(h *toukibo.Houjin) Record {
+	rec := Record{
+		CorporateNumber: h.CorporateNumber(),
+		Process:         ProcessNew,
+		Correct:         "0",
+		Name:            h.CompanyName,
+		Kind:            KindCode(h.HoujinType),
+		Latest:          "1",
+		Hihyoji:         "0",
+	}
+	if !h.CreatedAt.IsZero() {
+		rec.UpdateDate = h.CreatedAt.Format("2006-01-02")
+		rec.ChangeDate = rec.UpdateDate
+	}
+
+	a := h.Jusho()
+	rec.PrefectureName = a.Prefecture
+	rec.PrefectureCode = jusho.PrefectureCode(a.Prefecture)
+	rec.CityName = a.County + a.City + a.Ward
+	rec.StreetNumber = streetNumber(a)
+	if h.Foreign != nil {
+		rec.AddressOutside = h.Foreign.HomeHeadOffice
+	}
+
+	if k := h.Closure(); k != nil {
+		rec.Process = ProcessClose
+		rec.CloseDate = isoDate(k.ClosedDate)
+		rec.CloseCause = closeCause(*k)
+	}
+	return rec
+}
+
+// Writer は法人を全件データと同じ列の並びのCSVで書き出す
+type Writer struct {
+	w   *csv.Writer
+	seq int
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: csv.NewWriter(w)}
+}
+
+// Write は h を1行書き出す。一連番号は1から順に振る
+func (w *Writer) Write(h *toukibo.Houjin) error {
+	rec := NewRecord(h)
+	return w.WriteRecord(rec)
+}
+
+// WriteRecord は rec に一連番号を振って書き出す
+func (w *Writer) WriteRecord(rec Record) error {
+	w.seq++
+	rec.SequenceNumber = strconv.Itoa(w.seq)
+	return w.w.Write(rec.Values())
+}
+
+// Flush は書き出しを終える
+func (w *Writer) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
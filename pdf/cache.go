@@ -0,0 +1,155 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// Cache sizes, in objects, decoded object streams and CIDFonts.
+// Certificates issued by the registry are at most a few hundred objects,
+// so in practice the caches hold the whole file.
+const (
+	objCacheSize     = 4096
+	objStmCacheSize  = 64
+	metricsCacheSize = 64
+)
+
+// An objCache is a bounded cache keyed by object pointer.
+// When full, it evicts the least recently used entry.
+type objCache struct {
+	mu  sync.Mutex
+	max int
+	ll  *list.List
+	m   map[objptr]*list.Element
+}
+
+type cacheEntry struct {
+	ptr objptr
+	val interface{}
+}
+
+func newObjCache(max int) *objCache {
+	return &objCache{max: max, ll: list.New(), m: make(map[objptr]*list.Element)}
+}
+
+func (c *objCache) get(ptr objptr) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.m[ptr]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*cacheEntry).val, true
+}
+
+func (c *objCache) put(ptr objptr, val interface{}) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.m[ptr]; ok {
+		e.Value.(*cacheEntry).val = val
+		c.ll.MoveToFront(e)
+		return
+	}
+	c.m[ptr] = c.ll.PushFront(&cacheEntry{ptr, val})
+	for c.ll.Len() > c.max {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.m, e.Value.(*cacheEntry).ptr)
+	}
+}
+
+// reset empties the cache.
+func (c *objCache) reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.m = make(map[objptr]*list.Element)
+}
+
+// An objStm is a decoded object stream (PDF 32000-1:2008, §7.5.7).
+type objStm struct {
+	ptr     objptr
+	data    []byte
+	offsets map[uint32]int64 // object number → offset in data
+	extends Value
+}
+
+// objStm returns the decoded object stream strm, decoding it on first use.
+func (r *Reader) objStm(strm Value) (*objStm, error) {
+	if s, ok := r.objstm.get(strm.ptr); ok {
+		return s.(*objStm), nil
+	}
+	if strm.Key("Type").Name() != "ObjStm" {
+		return nil, newError(strm.ptr, -1, "not an object stream")
+	}
+	n := int(strm.Key("N").Int64())
+	first := strm.Key("First").Int64()
+	if first == 0 {
+		return nil, newError(strm.ptr, -1, "object stream missing First")
+	}
+	rd, err := strm.ReaderErr()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return nil, wrapError(strm.ptr, -1, err)
+	}
+	if first > int64(len(data)) {
+		return nil, newError(strm.ptr, -1, "object stream First %d beyond data length %d", first, len(data))
+	}
+
+	s := &objStm{ptr: strm.ptr, data: data, offsets: make(map[uint32]int64, n)}
+	b := newBuffer(bytes.NewReader(data[:first]), 0)
+	b.allowEOF = true
+	for i := 0; i < n; i++ {
+		id, ok1 := b.readToken().(int64)
+		off, ok2 := b.readToken().(int64)
+		if !ok1 || !ok2 {
+			break
+		}
+		if _, ok := s.offsets[uint32(id)]; !ok {
+			s.offsets[uint32(id)] = first + off
+		}
+	}
+	s.extends, err = strm.KeyErr("Extends")
+	if err != nil {
+		return nil, err
+	}
+	r.objstm.put(strm.ptr, s)
+	return s, nil
+}
+
+// read parses the object stored at offset in the object stream.
+func (s *objStm) read(offset int64) (object, error) {
+	if offset < 0 || offset >= int64(len(s.data)) {
+		return nil, &Error{Obj: s.ptr.id, Gen: s.ptr.gen, Offset: offset, InStream: true, Err: io.ErrUnexpectedEOF}
+	}
+	b := newBuffer(bytes.NewReader(s.data[offset:]), offset)
+	b.allowEOF = true
+	obj, err := b.readObject()
+	if err != nil {
+		if e, ok := err.(*Error); ok {
+			err = &Error{Obj: s.ptr.id, Gen: s.ptr.gen, Offset: e.Offset, InStream: true, Err: e.Err}
+		}
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,239 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// benchPages is the size of the generated certificate. Registry
+// certificates of large companies with long histories run to about this.
+const benchPages = 100
+
+// benchPDF returns a PDF file with the given number of pages, laid out
+// like a registry certificate: a Type0 font with a ToUnicode CMap, a
+// two-level page tree, Flate-compressed streams, and every non-stream
+// object in object streams indexed by a cross-reference stream.
+func benchPDF(pages int) []byte {
+	type obj struct {
+		data   string
+		stream bool
+	}
+	objs := []obj{{}} // object 0 is the free list head
+	add := func(data string, stream bool) int {
+		objs = append(objs, obj{data, stream})
+		return len(objs) - 1
+	}
+	addStream := func(dict, data string) int {
+		var z bytes.Buffer
+		w := zlib.NewWriter(&z)
+		w.Write([]byte(data))
+		w.Close()
+		return add(fmt.Sprintf("<<%s /Filter/FlateDecode/Length %d>>\nstream\n%s\nendstream", dict, z.Len(), z.Bytes()), true)
+	}
+
+	catalog := add("", false)
+	root := add("", false)
+	cmap := addStream("", "/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n"+
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n"+
+		"1 beginbfrange\n<0020> <007E> <0020>\nendbfrange\nendcmap\nend\nend")
+	cidFont := add("<</Type/Font/Subtype/CIDFontType2/BaseFont/MS-Mincho"+
+		"/CIDSystemInfo<</Registry(Adobe)/Ordering(Japan1)/Supplement 2>>/DW 1000>>", false)
+	font := add(fmt.Sprintf("<</Type/Font/Subtype/Type0/BaseFont/MS-Mincho/Encoding/Identity-H"+
+		"/DescendantFonts[%d 0 R]/ToUnicode %d 0 R>>", cidFont, cmap), false)
+
+	var nodes []string
+	for first := 0; first < pages; first += 10 {
+		node := add("", false)
+		var kids []string
+		for i := first; i < first+10 && i < pages; i++ {
+			var content strings.Builder
+			content.WriteString("BT /F1 10 Tf\n")
+			for line := 0; line < 40; line++ {
+				fmt.Fprintf(&content, "1 0 0 1 50 %d Tm <", 780-line*18)
+				for _, c := range fmt.Sprintf("Page %d line %d of the certificate", i+1, line+1) {
+					fmt.Fprintf(&content, "%04X", c)
+				}
+				content.WriteString("> Tj\n")
+			}
+			content.WriteString("ET")
+			c := addStream("", content.String())
+			p := add(fmt.Sprintf("<</Type/Page/Parent %d 0 R/MediaBox[0 0 595 842]"+
+				"/Resources<</Font<</F1 %d 0 R>>>>/Contents %d 0 R>>", node, font, c), false)
+			kids = append(kids, fmt.Sprintf("%d 0 R", p))
+		}
+		objs[node].data = fmt.Sprintf("<</Type/Pages/Parent %d 0 R/Kids[%s]/Count %d>>", root, strings.Join(kids, " "), len(kids))
+		nodes = append(nodes, fmt.Sprintf("%d 0 R", node))
+	}
+	objs[root].data = fmt.Sprintf("<</Type/Pages/Kids[%s]/Count %d>>", strings.Join(nodes, " "), pages)
+	objs[catalog].data = fmt.Sprintf("<</Type/Catalog/Pages %d 0 R>>", root)
+
+	// Pack the non-stream objects into object streams of 100 objects.
+	type entry struct{ typ, f2, f3 int }
+	xref := make([]entry, len(objs))
+	var packed []int
+	for id := 1; id < len(objs); id++ {
+		if !objs[id].stream {
+			packed = append(packed, id)
+		}
+	}
+	for len(packed) > 0 {
+		n := len(packed)
+		if n > 100 {
+			n = 100
+		}
+		var head, body strings.Builder
+		for i, id := range packed[:n] {
+			fmt.Fprintf(&head, "%d %d ", id, body.Len())
+			body.WriteString(objs[id].data)
+			body.WriteString("\n")
+			xref[id] = entry{2, len(objs), i}
+		}
+		addStream(fmt.Sprintf("/Type/ObjStm/N %d/First %d", n, head.Len()), head.String()+body.String())
+		xref = append(xref, entry{})
+		packed = packed[n:]
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.5\n")
+	for id := 1; id < len(objs); id++ {
+		if objs[id].stream {
+			xref[id] = entry{1, out.Len(), 0}
+			fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", id, objs[id].data)
+		}
+	}
+	xrefID := len(objs)
+	xref = append(xref, entry{1, out.Len(), 0})
+	xref[0] = entry{0, 0, 65535}
+	var table bytes.Buffer
+	for _, e := range xref {
+		table.Write([]byte{byte(e.typ), byte(e.f2 >> 24), byte(e.f2 >> 16), byte(e.f2 >> 8), byte(e.f2), byte(e.f3 >> 8), byte(e.f3)})
+	}
+	start := out.Len()
+	fmt.Fprintf(&out, "%d 0 obj\n<</Type/XRef/Size %d/W[1 4 2]/Root %d 0 R/Length %d>>\nstream\n", xrefID, len(xref), catalog, table.Len())
+	out.Write(table.Bytes())
+	fmt.Fprintf(&out, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", start)
+	return out.Bytes()
+}
+
+// disableCache makes r parse every object and decode every object stream
+// each time it is used, as Reader did before it had caches.
+func disableCache(r *Reader) {
+	r.cache = nil
+	r.objstm = nil
+	r.metrics = nil
+}
+
+// uncachedPage returns page num of r after walking the page tree from the
+// root again, as Page did before the tree was indexed.
+func uncachedPage(r *Reader, num int) Page {
+	r.pages, r.pagesOnce = nil, sync.Once{}
+	return r.Page(num)
+}
+
+// uncachedPlainText does what Reader.GetPlainText does, but with
+// disableCache and uncachedPage.
+func uncachedPlainText(r *Reader) (io.Reader, error) {
+	disableCache(r)
+	var buf bytes.Buffer
+	fonts := make(map[string]*Font)
+	for i := 1; i <= r.NumPage(); i++ {
+		p := uncachedPage(r, i)
+		for _, name := range p.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				f := p.Font(name)
+				fonts[name] = &f
+			}
+		}
+		text, err := p.GetPlainText(fonts)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(text)
+	}
+	return &buf, nil
+}
+
+var benchData struct {
+	once sync.Once
+	pdf  []byte
+}
+
+func benchFile(b *testing.B) []byte {
+	benchData.once.Do(func() {
+		benchData.pdf = benchPDF(benchPages)
+	})
+	return benchData.pdf
+}
+
+func BenchmarkGetPlainText(b *testing.B) {
+	data := benchFile(b)
+	for _, cached := range []bool{true, false} {
+		name := "cached"
+		if !cached {
+			name = "uncached"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				r, err := NewReaderFromBytes(data)
+				if err != nil {
+					b.Fatal(err)
+				}
+				var rd io.Reader
+				if cached {
+					rd, err = r.GetPlainText()
+				} else {
+					rd, err = uncachedPlainText(r)
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+				text, _ := io.ReadAll(rd)
+				if !bytes.Contains(text, []byte(fmt.Sprintf("Page %d line 40", benchPages))) {
+					b.Fatalf("text of the last page is missing")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPage reads the contents of every page in turn.
+func BenchmarkPage(b *testing.B) {
+	data := benchFile(b)
+	for _, cached := range []bool{true, false} {
+		name := "cached"
+		if !cached {
+			name = "uncached"
+		}
+		b.Run(name, func(b *testing.B) {
+			r, err := NewReaderFromBytes(data)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if !cached {
+				disableCache(r)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var p Page
+				if cached {
+					p = r.Page(i%benchPages + 1)
+				} else {
+					p = uncachedPage(r, i%benchPages+1)
+				}
+				if p.V.Key("Contents").Kind() != Stream {
+					b.Fatalf("page %d has no contents", i%benchPages+1)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import "strings"
+
+// Glyph metrics of composite fonts (PDF 32000-1:2008, §9.7.4.3 and §9.7.5).
+
+// A charCode is one character code of a shown string.
+type charCode struct {
+	code string
+	cid  int // CID selected in a composite font; -1 if unknown or simple
+}
+
+// A cidMetrics holds the widths of a CIDFont: the /W and /DW entries for
+// horizontal writing and the /W2 and /DW2 entries for vertical writing.
+type cidMetrics struct {
+	dw  float64
+	w   map[int]float64
+	dw2 [2]float64 // vy, w1y
+	w2  map[int][3]float64
+}
+
+// readCIDMetrics returns the metrics of the descendant font of the Type0
+// font f. A descendant font stored as an indirect object is parsed once
+// and then found in the Reader's cache.
+func readCIDMetrics(f Font) *cidMetrics {
+	var ptr objptr
+	if a, ok := f.V.Key("DescendantFonts").data.(array); ok && len(a) > 0 {
+		ptr, _ = a[0].(objptr)
+	}
+	if f.V.r == nil || ptr == (objptr{}) {
+		return parseCIDMetrics(f.V.Key("DescendantFonts").Index(0))
+	}
+	if m, ok := f.V.r.metrics.get(ptr); ok {
+		return m.(*cidMetrics)
+	}
+	m := parseCIDMetrics(f.V.Key("DescendantFonts").Index(0))
+	f.V.r.metrics.put(ptr, m)
+	return m
+}
+
+// parseCIDMetrics reads the /W, /DW, /W2 and /DW2 entries of the CIDFont d.
+func parseCIDMetrics(d Value) *cidMetrics {
+	m := &cidMetrics{dw: 1000, w: make(map[int]float64), dw2: [2]float64{880, -1000}, w2: make(map[int][3]float64)}
+	if dw := d.Key("DW"); dw.Kind() == Integer || dw.Kind() == Real {
+		m.dw = dw.Float64()
+	}
+	if dw2 := d.Key("DW2"); dw2.Len() == 2 {
+		m.dw2 = [2]float64{dw2.Index(0).Float64(), dw2.Index(1).Float64()}
+	}
+	readCIDWidths(d.Key("W"), 1, func(cid int, w []float64) {
+		m.w[cid] = w[0]
+	})
+	readCIDWidths(d.Key("W2"), 3, func(cid int, w []float64) {
+		m.w2[cid] = [3]float64{w[0], w[1], w[2]}
+	})
+	return m
+}
+
+// maxCID is the largest CID a CIDFont can have.
+const maxCID = 65535
+
+// readCIDWidths reads a /W or /W2 array, whose entries have n numbers each,
+// calling set for every CID it lists. The array holds both
+//
+//	c [w1 w2 ...]        widths of c, c+1, ...
+//	cfirst clast w...    the same widths for cfirst through clast
+//
+// CIDs outside 0 through maxCID are skipped, so that a range such as
+// 0 2000000000 does not run for billions of iterations.
+func readCIDWidths(a Value, n int, set func(cid int, w []float64)) {
+	w := make([]float64, n)
+	for i := 0; i < a.Len(); {
+		c := a.Index(i).Int64()
+		if x := a.Index(i + 1); x.Kind() == Array {
+			for j := 0; j+n <= x.Len(); j += n {
+				cid := c + int64(j/n)
+				if cid < 0 || cid > maxCID {
+					continue
+				}
+				for k := range w {
+					w[k] = x.Index(j + k).Float64()
+				}
+				set(int(cid), w)
+			}
+			i += 2
+			continue
+		}
+		last := a.Index(i + 1).Int64()
+		if i+2+n > a.Len() || last < c {
+			return
+		}
+		for k := range w {
+			w[k] = a.Index(i + 2 + k).Float64()
+		}
+		if c < 0 {
+			c = 0
+		}
+		if last > maxCID {
+			last = maxCID
+		}
+		for cid := c; cid <= last; cid++ {
+			set(int(cid), w)
+		}
+		i += 2 + n
+	}
+}
+
+// width returns the horizontal width of cid, in glyph space units.
+func (m *cidMetrics) width(cid int) float64 {
+	if w, ok := m.w[cid]; ok {
+		return w
+	}
+	return m.dw
+}
+
+// vertical returns the vertical displacement w1y of cid and the position
+// vector (vx, vy) from its horizontal origin to its vertical one.
+func (m *cidMetrics) vertical(cid int) (w1y, vx, vy float64) {
+	if v, ok := m.w2[cid]; ok {
+		return v[0], v[1], v[2]
+	}
+	return m.dw2[1], m.width(cid) / 2, m.dw2[0]
+}
+
+// A fontMetrics splits the strings shown with a font into character codes
+// and gives their widths. Content builds one for each font it uses.
+type fontMetrics struct {
+	font      Font
+	composite bool
+	vertical  bool
+	split     func(raw string) charCode // splits off the first code of raw
+	cid       *cidMetrics
+}
+
+func newFontMetrics(f Font) *fontMetrics {
+	m := &fontMetrics{font: f}
+	if f.V.Key("Subtype").Name() != "Type0" {
+		m.split = func(raw string) charCode {
+			return charCode{raw[:1], -1}
+		}
+		return m
+	}
+	m.composite = true
+	m.cid = readCIDMetrics(f)
+	m.split = twoByteCodes
+
+	enc := f.V.Key("Encoding")
+	name := enc.Name()
+	if enc.Kind() == Stream {
+		cm := readCmap(enc)
+		name = enc.Key("UseCMap").Name()
+		if cm != nil && cm.usecmap != "" {
+			name = cm.usecmap
+		}
+		m.vertical = enc.Key("WMode").Int64() == 1
+		if cm != nil && cm.hasCodespace() {
+			m.split = func(raw string) charCode {
+				n := cm.codeLen(raw)
+				if n == 0 {
+					n = 1
+				}
+				return charCode{raw[:n], cm.cid(raw[:n])}
+			}
+			return m
+		}
+	}
+	m.vertical = m.vertical || name == "V" || strings.HasSuffix(name, "-V")
+
+	info := f.V.Key("DescendantFonts").Index(0).Key("CIDSystemInfo")
+	japan1 := info.Key("Registry").RawString() == "Adobe" && info.Key("Ordering").RawString() == "Japan1"
+	if dec := predefinedCMap(name); dec != nil {
+		halfwidth := halfwidthASCII(name)
+		m.split = func(raw string) charCode {
+			r, n := dec(raw)
+			c := charCode{raw[:n], -1}
+			if japan1 && r != noRune {
+				c.cid = japan1CID(r, halfwidth)
+			}
+			return c
+		}
+	}
+	return m
+}
+
+// twoByteCodes splits off a two-byte code that is its own CID,
+// as Identity-H and Identity-V do.
+func twoByteCodes(raw string) charCode {
+	if len(raw) < 2 {
+		return charCode{raw, -1}
+	}
+	return charCode{raw[:2], int(raw[0])<<8 | int(raw[1])}
+}
+
+// width returns the horizontal width of c, in glyph space units.
+func (m *fontMetrics) width(c charCode) float64 {
+	if !m.composite {
+		return m.font.Width(int(c.code[0]))
+	}
+	if c.cid < 0 {
+		return m.cid.dw
+	}
+	return m.cid.width(c.cid)
+}
+
+// VerticalMetrics returns the vertical metrics of a CID in a composite font
+// used in vertical writing mode: the vertical displacement w1y and the
+// position vector (vx, vy) from the glyph's horizontal origin to its
+// vertical origin, all in glyph space units.
+// They come from the descendant font's /W2 and /DW2 entries.
+func (f Font) VerticalMetrics(cid int) (w1y, vx, vy float64) {
+	return readCIDMetrics(f).vertical(cid)
+}
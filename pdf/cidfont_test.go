@@ -0,0 +1,24 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import "testing"
+
+func TestCIDWidthsRange(t *testing.T) {
+	w := array{
+		int64(-5), int64(2000000000), int64(500),
+		int64(65534), array{int64(600), int64(700), int64(800)},
+	}
+	n := 0
+	readCIDWidths(Value{data: w}, 1, func(cid int, w []float64) {
+		if cid < 0 || cid > maxCID {
+			t.Fatalf("set called for CID %d", cid)
+		}
+		n++
+	})
+	if n != maxCID+1+2 {
+		t.Errorf("set called %d times, want %d", n, maxCID+1+2)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A CIDTable maps the CIDs of one Adobe character collection to Unicode.
+// It is used to decode Identity-H and Identity-V fonts that have no
+// ToUnicode CMap, in place of the partial tables built into the package.
+type CIDTable struct {
+	Ordering string // Japan1, GB1, CNS1 or Korea1
+	Runes    map[int]rune
+}
+
+// cidTableOrderings maps the Unicode columns of cid2code.txt to the
+// collections they belong to.
+var cidTableOrderings = map[string]string{
+	"UniJIS": "Japan1",
+	"UniGB":  "GB1",
+	"UniCNS": "CNS1",
+	"UniKS":  "Korea1",
+}
+
+// ReadCIDTable reads the cid2code.txt file that Adobe publishes with each
+// character collection (Adobe-Japan1-7, Adobe-GB1-5, Adobe-CNS1-7,
+// Adobe-Korea1-2). The file has a header line naming its columns,
+//
+//	CID	...	UniJIS-UTF32	...
+//
+// and a line per CID. ReadCIDTable uses the UTF-32 column of the
+// collection's Unicode CMaps, or the UCS-2 column in files that have none.
+// A cell holds hexadecimal code points separated by commas, the first of
+// which is used; a v suffix marks a code that only the vertical CMap maps
+// to the CID, such as the rotated punctuation of Adobe-Japan1 from CID
+// 7887 on, which is decoded as the horizontal character; * marks a CID
+// that no code maps to.
+func ReadCIDTable(rd io.Reader) (*CIDTable, error) {
+	t := &CIDTable{Runes: make(map[int]rune)}
+	col := -1
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(nil, 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		f := strings.Split(text, "\t")
+		if col < 0 {
+			if f[0] != "CID" {
+				return nil, fmt.Errorf("cid2code line %d: missing CID header", line)
+			}
+			col = cidTableColumn(t, f)
+			if col < 0 {
+				return nil, fmt.Errorf("cid2code line %d: no Unicode column", line)
+			}
+			continue
+		}
+		cid, err := strconv.Atoi(f[0])
+		if err != nil || cid < 0 || cid > maxCID || len(f) <= col {
+			return nil, fmt.Errorf("cid2code line %d: malformed", line)
+		}
+		cell, _, _ := strings.Cut(f[col], ",")
+		cell = strings.TrimSuffix(cell, "v")
+		if cell == "*" {
+			continue
+		}
+		n, err := strconv.ParseUint(cell, 16, 32)
+		if err != nil || n > 0x10FFFF {
+			return nil, fmt.Errorf("cid2code line %d: bad code %q", line, f[col])
+		}
+		t.Runes[cid] = rune(n)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if col < 0 {
+		return nil, fmt.Errorf("cid2code: missing CID header")
+	}
+	return t, nil
+}
+
+// cidTableColumn returns the index of the column of header to read,
+// preferring UTF-32 to UCS-2, and sets t.Ordering. It returns -1 if
+// the header names no Unicode column.
+func cidTableColumn(t *CIDTable, header []string) int {
+	for _, enc := range []string{"-UTF32", "-UCS2"} {
+		for i, name := range header {
+			if ordering, ok := cidTableOrderings[strings.TrimSuffix(name, enc)]; ok && strings.HasSuffix(name, enc) {
+				t.Ordering = ordering
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// SetCIDTable sets the table used to decode Identity-H and Identity-V
+// fonts in t's character collection that have no ToUnicode CMap.
+// It must be called before any text is extracted.
+func (r *Reader) SetCIDTable(t *CIDTable) {
+	if r.cidTables == nil {
+		r.cidTables = make(map[string]*CIDTable)
+	}
+	r.cidTables[t.Ordering] = t
+}
+
+func (t *CIDTable) rune(cid int) rune {
+	if r, ok := t.Runes[cid]; ok {
+		return r
+	}
+	return noRune
+}
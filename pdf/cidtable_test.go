@@ -0,0 +1,52 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+// Lines in the layout of Adobe's cid2code.txt; not all the mappings are real.
+const cid2codeTest = `# Adobe-Japan1-7
+CID	H	V	UniJIS-UCS2	UniJIS-UTF32	UniJIS2004-UTF32
+0	*	*	*	*	*
+1	2121	2121	0020	00000020	00000020
+1200	3021	3021	4E9C	00004E9C	00004E9C
+7887	*	2122	3001v	00003001v	00003001v
+8286	*	*	*	0000FA30,00004FAE	0000FA30
+13320	*	*	*	00020B9F	00020B9F
+`
+
+func TestReadCIDTable(t *testing.T) {
+	tab, err := ReadCIDTable(strings.NewReader(cid2codeTest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tab.Ordering != "Japan1" {
+		t.Errorf("Ordering = %q, want Japan1", tab.Ordering)
+	}
+	want := map[int]rune{1: ' ', 1200: '亜', 7887: '、', 8286: '\uFA30', 13320: '\U00020B9F'}
+	if len(tab.Runes) != len(want) {
+		t.Errorf("read %d CIDs, want %d", len(tab.Runes), len(want))
+	}
+	for cid, r := range want {
+		if tab.Runes[cid] != r {
+			t.Errorf("CID %d = %U, want %U", cid, tab.Runes[cid], r)
+		}
+	}
+
+	dec := identityDecoder("Japan1", tab)
+	if r, n := dec("\x1e\xcf"); r != '、' || n != 2 {
+		t.Errorf("decode CID 7887 = %U, %d, want U+3001, 2", r, n)
+	}
+	if r, _ := dec("\x00\x02"); r != noRune {
+		t.Errorf("decode CID 2 = %U, want U+FFFD", r)
+	}
+
+	if _, err := ReadCIDTable(strings.NewReader("CID\tH\tV\n1\t2121\t2121\n")); err == nil {
+		t.Error("file without a Unicode column read without error")
+	}
+}
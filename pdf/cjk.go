@@ -0,0 +1,431 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Predefined CMaps (PDF 32000-1:2008, §9.7.5.2).
+//
+// A Type0 font without a ToUnicode CMap is decoded through the predefined
+// CMap named by its Encoding. The Unicode-based CMaps need no tables: the
+// character codes are already Unicode, whatever the character collection.
+// The legacy Japanese CMaps are decoded with the JIS X 0208 table in
+// jisx0208.txt; the legacy Chinese and Korean CMaps are not supported.
+//
+// Identity-H and Identity-V show CIDs, which need a table per character
+// collection. Adobe's cid2code.txt for any collection can be loaded with
+// ReadCIDTable and SetCIDTable. Without one, only part of Adobe-Japan1 is
+// built in: the Latin, half-width katakana and JIS X 0208 CIDs of
+// Adobe-Japan1-0 and -1, which cover the text of registry certificates.
+// The other CIDs of Adobe-Japan1, among them everything the supplements
+// from Adobe-Japan1-2 on add, and all but the Latin CIDs of Adobe-GB1,
+// Adobe-CNS1 and Adobe-Korea1, then decode to U+FFFD unless the gaiji
+// table maps them.
+
+// A cidDecoder decodes the first character code in raw, returning the
+// character and the length of the code. It returns noRune for a code
+// it cannot map, with a length of at least 1.
+type cidDecoder func(raw string) (rune, int)
+
+// predefinedCMaps maps the name of a predefined CMap, without its -H or -V
+// writing mode suffix, to its decoder. The vertical CMaps select different
+// glyphs for the same text, so both modes decode alike.
+var predefinedCMaps = map[string]cidDecoder{
+	// Unicode, for any character collection.
+	"UniJIS-UCS2":           decodeUCS2,
+	"UniJIS-UCS2-HW":        decodeUCS2,
+	"UniJISPro-UCS2":        decodeUCS2,
+	"UniJISPro-UCS2-HW":     decodeUCS2,
+	"UniJIS-UTF16":          decodeUTF16,
+	"UniJIS2004-UTF16":      decodeUTF16,
+	"UniJIS-UTF8":           decodeUTF8,
+	"UniJIS2004-UTF8":       decodeUTF8,
+	"UniJISPro-UTF8":        decodeUTF8,
+	"UniJIS-UTF32":          decodeUTF32,
+	"UniJIS2004-UTF32":      decodeUTF32,
+	"UniJISX0213-UTF32":     decodeUTF32,
+	"UniJISX02132004-UTF32": decodeUTF32,
+	"UniGB-UCS2":            decodeUCS2,
+	"UniGB-UTF16":           decodeUTF16,
+	"UniGB-UTF8":            decodeUTF8,
+	"UniGB-UTF32":           decodeUTF32,
+	"UniCNS-UCS2":           decodeUCS2,
+	"UniCNS-UTF16":          decodeUTF16,
+	"UniCNS-UTF8":           decodeUTF8,
+	"UniCNS-UTF32":          decodeUTF32,
+	"UniKS-UCS2":            decodeUCS2,
+	"UniKS-UTF16":           decodeUTF16,
+	"UniKS-UTF8":            decodeUTF8,
+	"UniKS-UTF32":           decodeUTF32,
+
+	// Shift_JIS.
+	"RKSJ":       decodeSJIS,
+	"78-RKSJ":    decodeSJIS,
+	"83pv-RKSJ":  decodeSJIS,
+	"90ms-RKSJ":  decodeSJIS,
+	"90msp-RKSJ": decodeSJIS,
+	"90pv-RKSJ":  decodeSJIS,
+	"78ms-RKSJ":  decodeSJIS,
+	"Add-RKSJ":   decodeSJIS,
+	"Ext-RKSJ":   decodeSJIS,
+
+	// EUC-JP.
+	"EUC":    decodeEUCJP,
+	"78-EUC": decodeEUCJP,
+
+	// ISO-2022-JP: JIS X 0208 row and cell as two 7-bit bytes.
+	"":    decodeJIS,
+	"78":  decodeJIS,
+	"Add": decodeJIS,
+	"Ext": decodeJIS,
+}
+
+// predefinedCMap returns the decoder for the predefined CMap name,
+// or nil if there is none.
+func predefinedCMap(name string) cidDecoder {
+	switch {
+	case name == "H" || name == "V":
+		name = ""
+	case strings.HasSuffix(name, "-H") || strings.HasSuffix(name, "-V"):
+		name = name[:len(name)-2]
+	default:
+		return nil
+	}
+	return predefinedCMaps[name]
+}
+
+// A cidEncoder decodes text shown with a Type0 font that has no ToUnicode
+// CMap. Codes it cannot map are looked up in the gaiji table.
+type cidEncoder struct {
+	decode cidDecoder
+	gaiji  *GaijiMap
+	font   string
+}
+
+func (e *cidEncoder) Decode(raw string) (text string) {
+	r := make([]rune, 0, len(raw))
+	for len(raw) > 0 {
+		c, n := e.decode(raw)
+		if c == noRune {
+			if s, ok := e.gaiji.lookupCID(e.font, raw[:n]); ok {
+				r = append(r, []rune(s)...)
+				raw = raw[n:]
+				continue
+			}
+		}
+		r = append(r, c)
+		raw = raw[n:]
+	}
+	return string(r)
+}
+
+// cidEncoding returns the encoding of a Type0 font with no ToUnicode CMap,
+// or nil if the font's Encoding is not one this package can decode.
+func (f *Font) cidEncoding() TextEncoding {
+	name := f.V.Key("Encoding").Name()
+	dec := predefinedCMap(name)
+	if name == "Identity-H" || name == "Identity-V" {
+		info := f.V.Key("DescendantFonts").Index(0).Key("CIDSystemInfo")
+		if info.Key("Registry").RawString() == "Adobe" {
+			var t *CIDTable
+			if f.V.r != nil {
+				t = f.V.r.cidTables[info.Key("Ordering").RawString()]
+			}
+			dec = identityDecoder(info.Key("Ordering").RawString(), t)
+		}
+	}
+	if dec == nil {
+		return nil
+	}
+	e := &cidEncoder{decode: dec}
+	if f.V.r != nil {
+		e.gaiji = f.V.r.gaiji
+		e.font = f.BaseFont()
+	}
+	return e
+}
+
+// identityDecoder returns the decoder for two-byte CIDs in the
+// character collection Adobe-ordering, or nil if it is unknown.
+// It decodes with t if it is not nil, else with the built-in table.
+func identityDecoder(ordering string, t *CIDTable) cidDecoder {
+	var cidRune func(cid int) rune
+	switch {
+	case t != nil:
+		cidRune = t.rune
+	case ordering == "Japan1":
+		cidRune = japan1Rune
+	case ordering == "GB1" || ordering == "CNS1" || ordering == "Korea1":
+		// The Latin CIDs only; see latinCIDRune.
+		cidRune = latinCIDRune
+	default:
+		return nil
+	}
+	return func(raw string) (rune, int) {
+		if len(raw) < 2 {
+			return noRune, len(raw)
+		}
+		return cidRune(int(raw[0])<<8 | int(raw[1])), 2
+	}
+}
+
+// latinCIDRune maps the proportional Latin CIDs 1-95, which the Adobe
+// character collections share, to ASCII. The other CIDs of Adobe-GB1,
+// Adobe-CNS1 and Adobe-Korea1 are not in any encoding's order, and their
+// tables are not included in this package, so text in those collections
+// is only decoded through ToUnicode, a Unicode CMap or a CIDTable.
+func latinCIDRune(cid int) rune {
+	if 1 <= cid && cid <= 95 {
+		return rune(cid + 0x1F)
+	}
+	return noRune
+}
+
+// japan1Rune maps a CID in Adobe-Japan1 to Unicode. It covers the
+// JIS X 0208 repertoire of Adobe-Japan1-0 and -1: the proportional and
+// half-width JIS-Roman sets, the half-width katakana and the full-width
+// JIS X 0208 characters, which Adobe-Japan1 numbers in JIS order.
+// Other CIDs return noRune, among them CIDs 8286 and up, which
+// Adobe-Japan1-2 through -7 add.
+func japan1Rune(cid int) rune {
+	switch {
+	case 1 <= cid && cid <= 95:
+		return jisRomanRune(cid - 1)
+	case 231 <= cid && cid <= 325:
+		return jisRomanRune(cid - 231)
+	case 326 <= cid && cid <= 388:
+		return rune(0xFF61 + cid - 326)
+	case 633 <= cid && cid < 633+len(japan1JIS()):
+		return japan1JIS()[cid-633]
+	case cid == 8284:
+		return jisRune(84, 5)
+	case cid == 8285:
+		return jisRune(84, 6)
+	}
+	return noRune
+}
+
+// jisRomanRune returns the character at index i of JIS X 0201 Roman,
+// which is ASCII but for the yen sign and the overline.
+func jisRomanRune(i int) rune {
+	switch i {
+	case 0x5C - 0x20:
+		return '¥'
+	case 0x7E - 0x20:
+		return '‾'
+	}
+	return rune(0x20 + i)
+}
+
+var (
+	japan1Once  sync.Once
+	japan1Table []rune
+	japan1CIDs  map[rune]int // inverse of japan1Table, plus 8284-8285
+)
+
+// japan1JIS returns the characters of Adobe-Japan1 CIDs 633-7477:
+// the assigned cells of JIS X 0208 rows 1-7, then the kanji of rows 16-84
+// through row 84 cell 4.
+func japan1JIS() []rune {
+	japan1Once.Do(func() {
+		for row := 1; row <= 84; row++ {
+			if 8 <= row && row <= 15 {
+				continue
+			}
+			for cell := 1; cell <= 94; cell++ {
+				if row == 84 && cell > 4 {
+					break
+				}
+				if r := jisRune(row, cell); r != noRune {
+					japan1Table = append(japan1Table, r)
+				}
+			}
+		}
+		japan1CIDs = make(map[rune]int, len(japan1Table)+2)
+		for i, r := range japan1Table {
+			japan1CIDs[r] = 633 + i
+		}
+		japan1CIDs[jisRune(84, 5)] = 8284
+		japan1CIDs[jisRune(84, 6)] = 8285
+	})
+	return japan1Table
+}
+
+// japan1CID returns the Adobe-Japan1 CID that the predefined Japanese CMaps
+// select for r, or -1 if it is not known. ASCII selects the half-width
+// JIS-Roman CIDs if halfwidth is set and the proportional ones otherwise.
+func japan1CID(r rune, halfwidth bool) int {
+	switch {
+	case 0x20 <= r && r <= 0x7E && halfwidth:
+		return 231 + int(r) - 0x20
+	case 0x20 <= r && r <= 0x7E:
+		return 1 + int(r) - 0x20
+	case 0xFF61 <= r && r <= 0xFF9F:
+		return 326 + int(r) - 0xFF61
+	}
+	japan1JIS()
+	if cid, ok := japan1CIDs[r]; ok {
+		return cid
+	}
+	return -1
+}
+
+// halfwidthASCII reports whether the predefined CMap name maps ASCII
+// codes to the half-width JIS-Roman CIDs rather than the proportional ones.
+func halfwidthASCII(name string) bool {
+	switch {
+	case strings.HasPrefix(name, "90msp-"):
+		return false
+	case strings.Contains(name, "RKSJ"), strings.Contains(name, "EUC"), strings.Contains(name, "-HW-"):
+		return true
+	}
+	return false
+}
+
+//go:embed jisx0208.txt
+var jisx0208Text string
+
+var (
+	jisOnce  sync.Once
+	jisTable [][]rune // indexed by row-1, then cell-1
+)
+
+// jisRune returns the character at the given row and cell of JIS X 0208,
+// extended to rows 95-120 as in Windows code page 932,
+// or noRune if the cell is unassigned.
+func jisRune(row, cell int) rune {
+	jisOnce.Do(func() {
+		jisTable = make([][]rune, 120)
+		for _, line := range strings.Split(jisx0208Text, "\n") {
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			num, cells, ok := strings.Cut(line, "\t")
+			n, err := strconv.Atoi(num)
+			if !ok || err != nil || n < 1 || n > len(jisTable) || utf8.RuneCountInString(cells) != 94 {
+				panic("pdf: malformed jisx0208.txt: " + line)
+			}
+			jisTable[n-1] = []rune(cells)
+		}
+	})
+	if row < 1 || row > len(jisTable) || cell < 1 || cell > 94 || jisTable[row-1] == nil {
+		return noRune
+	}
+	return jisTable[row-1][cell-1]
+}
+
+func decodeUCS2(raw string) (rune, int) {
+	if len(raw) < 2 {
+		return noRune, len(raw)
+	}
+	return rune(raw[0])<<8 | rune(raw[1]), 2
+}
+
+func decodeUTF16(raw string) (rune, int) {
+	r, n := decodeUCS2(raw)
+	if utf16.IsSurrogate(r) && len(raw) >= 4 {
+		r2, _ := decodeUCS2(raw[2:])
+		if c := utf16.DecodeRune(r, r2); c != noRune {
+			return c, 4
+		}
+	}
+	return r, n
+}
+
+func decodeUTF8(raw string) (rune, int) {
+	return utf8.DecodeRuneInString(raw)
+}
+
+func decodeUTF32(raw string) (rune, int) {
+	if len(raw) < 4 {
+		return noRune, len(raw)
+	}
+	r := rune(raw[0])<<24 | rune(raw[1])<<16 | rune(raw[2])<<8 | rune(raw[3])
+	if !utf8.ValidRune(r) {
+		return noRune, 4
+	}
+	return r, 4
+}
+
+// decodeSJIS decodes a Shift_JIS code: ASCII, half-width katakana,
+// or a two-byte code for a JIS X 0208 row and cell.
+func decodeSJIS(raw string) (rune, int) {
+	b := raw[0]
+	switch {
+	case b < 0x80:
+		return rune(b), 1
+	case 0xA1 <= b && b <= 0xDF:
+		return rune(0xFF61 + int(b) - 0xA1), 1
+	case 0x81 <= b && b <= 0x9F, 0xE0 <= b && b <= 0xFC:
+	default:
+		return noRune, 1
+	}
+	if len(raw) < 2 {
+		return noRune, 1
+	}
+	t := raw[1]
+	if t < 0x40 || t == 0x7F || t > 0xFC {
+		return noRune, 2
+	}
+	lead := int(b) - 0x81
+	if b >= 0xE0 {
+		lead -= 0x40
+	}
+	row := lead*2 + 1
+	var cell int
+	switch {
+	case t >= 0x9F:
+		row++
+		cell = int(t) - 0x9E
+	case t >= 0x80:
+		cell = int(t) - 0x40
+	default:
+		cell = int(t) - 0x3F
+	}
+	return jisRune(row, cell), 2
+}
+
+// decodeEUCJP decodes an EUC-JP code: ASCII, half-width katakana,
+// or a JIS X 0208 row and cell. JIS X 0212 codes are not mapped.
+func decodeEUCJP(raw string) (rune, int) {
+	b := raw[0]
+	switch {
+	case b < 0x80:
+		return rune(b), 1
+	case b == 0x8F:
+		if len(raw) < 3 {
+			return noRune, len(raw)
+		}
+		return noRune, 3
+	case b != 0x8E && (b < 0xA1 || b > 0xFE):
+		return noRune, 1
+	}
+	if len(raw) < 2 {
+		return noRune, 1
+	}
+	t := raw[1]
+	if b == 0x8E {
+		if t < 0xA1 || t > 0xDF {
+			return noRune, 2
+		}
+		return rune(0xFF61 + int(t) - 0xA1), 2
+	}
+	return jisRune(int(b)-0xA0, int(t)-0xA0), 2
+}
+
+// decodeJIS decodes a two-byte ISO-2022-JP code.
+func decodeJIS(raw string) (rune, int) {
+	if len(raw) < 2 {
+		return noRune, len(raw)
+	}
+	return jisRune(int(raw[0])-0x20, int(raw[1])-0x20), 2
+}
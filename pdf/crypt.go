@@ -0,0 +1,543 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Decryption of files using the standard security handler
+// (PDF 32000-1:2008, §7.6, and ISO 32000-2:2017, §7.6.4 for AES-256).
+
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// A cryptMethod is the CFM of a crypt filter.
+type cryptMethod int
+
+const (
+	cryptIdentity cryptMethod = iota // no encryption
+	cryptRC4                         // V2
+	cryptAESV2                       // AES-128, per-object keys
+	cryptAESV3                       // AES-256, file key
+)
+
+func (m cryptMethod) String() string {
+	switch m {
+	case cryptRC4:
+		return "V2"
+	case cryptAESV2:
+		return "AESV2"
+	case cryptAESV3:
+		return "AESV3"
+	}
+	return "Identity"
+}
+
+// crypt holds the decryption state of an encrypted file.
+type crypt struct {
+	encrypt         objptr // the Encrypt dictionary, whose strings are not encrypted
+	key             []byte
+	stmf            cryptMethod
+	strf            cryptMethod
+	filters         map[name]cryptMethod // crypt filters by name, including Identity
+	encryptMetadata bool
+}
+
+func (c *crypt) ptr() objptr {
+	if c == nil {
+		return objptr{}
+	}
+	return c.encrypt
+}
+
+// streamMethod returns how to decrypt the stream v.
+// A stream whose first filter is Crypt names its own crypt filter.
+func (c *crypt) streamMethod(v Value, filter, param Value) (cryptMethod, error) {
+	switch v.Key("Type").Name() {
+	case "XRef":
+		return cryptIdentity, nil
+	case "Metadata":
+		if !c.encryptMetadata {
+			return cryptIdentity, nil
+		}
+	}
+	first, firstParam := filter, param
+	if filter.Kind() == Array {
+		first, firstParam = filter.Index(0), param.Index(0)
+	}
+	if first.Name() != "Crypt" {
+		return c.stmf, nil
+	}
+	cf := firstParam.Key("Name").Name()
+	if cf == "" {
+		cf = "Identity"
+	}
+	m, ok := c.filters[name(cf)]
+	if !ok {
+		return 0, fmt.Errorf("unknown crypt filter %s", cf)
+	}
+	return m, nil
+}
+
+var passwordPad = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// ErrInvalidPassword is returned when neither the user nor the owner
+// password of an encrypted file matches.
+var ErrInvalidPassword = fmt.Errorf("encrypted PDF: invalid password")
+
+func (r *Reader) initEncrypt(password string) error {
+	// See PDF 32000-1:2008, §7.6.
+	encptr, _ := r.trailer["Encrypt"].(objptr)
+	encrypt, _ := r.resolve(objptr{}, r.trailer["Encrypt"]).data.(dict)
+	if encrypt["Filter"] != name("Standard") {
+		return fmt.Errorf("unsupported PDF: encryption filter %v", objfmt(encrypt["Filter"]))
+	}
+	V, _ := encrypt["V"].(int64)
+	R, _ := encrypt["R"].(int64)
+	c := &crypt{encrypt: encptr, encryptMetadata: true}
+	if em, ok := encrypt["EncryptMetadata"].(bool); ok {
+		c.encryptMetadata = em
+	}
+
+	var err error
+	switch {
+	case V == 1 || V == 2:
+		c.stmf, c.strf = cryptRC4, cryptRC4
+		err = c.authRC4(r, encrypt, V, R, password)
+	case V == 4:
+		if err = c.readFilters(encrypt); err == nil {
+			err = c.authRC4(r, encrypt, V, R, password)
+		}
+	case V == 5:
+		if err = c.readFilters(encrypt); err == nil {
+			err = c.authAES256(encrypt, R, password)
+		}
+	default:
+		err = fmt.Errorf("unsupported PDF: encryption version V=%d; %v", V, objfmt(encrypt))
+	}
+	if err != nil {
+		return err
+	}
+
+	r.crypt = c
+	// Objects read before the key was known were not decrypted.
+	r.cache.reset()
+	r.objstm.reset()
+	r.metrics.reset()
+	return nil
+}
+
+// readFilters reads the crypt filters of a V4 or V5 Encrypt dictionary.
+func (c *crypt) readFilters(encrypt dict) error {
+	c.filters = map[name]cryptMethod{"Identity": cryptIdentity}
+	cf, _ := encrypt["CF"].(dict)
+	for n, x := range cf {
+		param, _ := x.(dict)
+		if param["AuthEvent"] != nil && param["AuthEvent"] != name("DocOpen") {
+			return fmt.Errorf("unsupported PDF: crypt filter %s AuthEvent %v", n, objfmt(param["AuthEvent"]))
+		}
+		switch param["CFM"] {
+		case nil, name("None"):
+			c.filters[n] = cryptIdentity
+		case name("V2"):
+			c.filters[n] = cryptRC4
+		case name("AESV2"):
+			c.filters[n] = cryptAESV2
+		case name("AESV3"):
+			c.filters[n] = cryptAESV3
+		default:
+			return fmt.Errorf("unsupported PDF: crypt filter %s method %v", n, objfmt(param["CFM"]))
+		}
+	}
+	lookup := func(key name) (cryptMethod, error) {
+		n, _ := encrypt[key].(name)
+		if n == "" {
+			n = "Identity"
+		}
+		m, ok := c.filters[n]
+		if !ok {
+			return 0, fmt.Errorf("malformed PDF: %s names unknown crypt filter %s", key, n)
+		}
+		return m, nil
+	}
+	var err error
+	if c.stmf, err = lookup("StmF"); err != nil {
+		return err
+	}
+	c.strf, err = lookup("StrF")
+	return err
+}
+
+// authRC4 authenticates password against a revision 2–4 handler and sets c.key.
+// The password is tried first as the user password and then as the owner password.
+func (c *crypt) authRC4(r *Reader, encrypt dict, V, R int64, password string) error {
+	n, _ := encrypt["Length"].(int64)
+	if n == 0 {
+		n = 40
+	}
+	if V == 4 {
+		n = 128
+	}
+	if n%8 != 0 || n > 128 || n < 40 {
+		return fmt.Errorf("malformed PDF: %d-bit encryption key", n)
+	}
+	if R < 2 {
+		return fmt.Errorf("malformed PDF: encryption revision R=%d", R)
+	}
+	if R > 4 {
+		return fmt.Errorf("unsupported PDF: encryption revision R=%d with V=%d", R, V)
+	}
+
+	ids, ok := r.trailer["ID"].(array)
+	if !ok || len(ids) < 1 {
+		return fmt.Errorf("malformed PDF: missing ID in trailer")
+	}
+	idstr, ok := ids[0].(string)
+	if !ok {
+		return fmt.Errorf("malformed PDF: missing ID in trailer")
+	}
+	ID := []byte(idstr)
+
+	O, _ := encrypt["O"].(string)
+	U, _ := encrypt["U"].(string)
+	if len(O) < 32 || len(U) < 32 {
+		return fmt.Errorf("malformed PDF: missing O= or U= encryption parameters")
+	}
+	O, U = O[:32], U[:32]
+	p, _ := encrypt["P"].(int64)
+	P := uint32(p)
+	keylen := int(n / 8)
+	if R == 2 {
+		keylen = 5
+	}
+
+	pw := pdfDocPassword(password)
+	if key := c.userKeyRC4(pw, O, U, P, ID, R, keylen); key != nil {
+		c.key = key
+		return nil
+	}
+
+	// Algorithm 7: the owner password decrypts O to the user password.
+	sum := md5.Sum(padPassword(pw))
+	if R >= 3 {
+		for i := 0; i < 50; i++ {
+			sum = md5.Sum(sum[:])
+		}
+	}
+	okey := sum[:keylen]
+	upw := []byte(O)
+	if R == 2 {
+		rc, _ := rc4.NewCipher(okey)
+		rc.XORKeyStream(upw, upw)
+	} else {
+		for i := 19; i >= 0; i-- {
+			k := make([]byte, len(okey))
+			for j := range k {
+				k[j] = okey[j] ^ byte(i)
+			}
+			rc, _ := rc4.NewCipher(k)
+			rc.XORKeyStream(upw, upw)
+		}
+	}
+	if key := c.userKeyRC4(upw, O, U, P, ID, R, keylen); key != nil {
+		c.key = key
+		return nil
+	}
+	return ErrInvalidPassword
+}
+
+// padPassword pads or truncates pw to 32 bytes (Algorithm 2, step a).
+func padPassword(pw []byte) []byte {
+	out := make([]byte, 32)
+	n := copy(out, pw)
+	copy(out[n:], passwordPad)
+	return out
+}
+
+// userKeyRC4 computes the file key from the user password pw (Algorithm 2)
+// and returns it if pw matches U (Algorithms 4 and 5), or nil otherwise.
+func (c *crypt) userKeyRC4(pw []byte, O, U string, P uint32, ID []byte, R int64, keylen int) []byte {
+	h := md5.New()
+	h.Write(padPassword(pw))
+	h.Write([]byte(O))
+	h.Write([]byte{byte(P), byte(P >> 8), byte(P >> 16), byte(P >> 24)})
+	h.Write(ID)
+	if R >= 4 && !c.encryptMetadata {
+		h.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	}
+	key := h.Sum(nil)
+
+	if R >= 3 {
+		for i := 0; i < 50; i++ {
+			h.Reset()
+			h.Write(key[:keylen])
+			key = h.Sum(key[:0])
+		}
+	}
+	key = key[:keylen]
+
+	rc, err := rc4.NewCipher(key)
+	if err != nil {
+		return nil
+	}
+	var u []byte
+	if R == 2 {
+		u = make([]byte, 32)
+		copy(u, passwordPad)
+		rc.XORKeyStream(u, u)
+	} else {
+		h.Reset()
+		h.Write(passwordPad)
+		h.Write(ID)
+		u = h.Sum(nil)
+		rc.XORKeyStream(u, u)
+
+		for i := 1; i <= 19; i++ {
+			key1 := make([]byte, len(key))
+			for j := range key1 {
+				key1[j] = key[j] ^ byte(i)
+			}
+			rc, _ = rc4.NewCipher(key1)
+			rc.XORKeyStream(u, u)
+		}
+	}
+	if !bytes.HasPrefix([]byte(U), u) {
+		return nil
+	}
+	return key
+}
+
+// pdfDocPassword converts a revision 2–4 password to PDFDocEncoding.
+// Characters that cannot be represented are dropped.
+func pdfDocPassword(password string) []byte {
+	var pw []byte
+	for _, r := range password {
+		for i, x := range pdfDocEncoding {
+			if x == r && r != noRune {
+				pw = append(pw, byte(i))
+				break
+			}
+		}
+	}
+	return pw
+}
+
+// authAES256 authenticates password against a revision 5 or 6 handler
+// (ISO 32000-2:2017, Algorithms 2.A, 11, and 12) and sets c.key.
+func (c *crypt) authAES256(encrypt dict, R int64, password string) error {
+	if R != 5 && R != 6 {
+		return fmt.Errorf("unsupported PDF: encryption revision R=%d with V=5", R)
+	}
+	O, _ := encrypt["O"].(string)
+	U, _ := encrypt["U"].(string)
+	OE, _ := encrypt["OE"].(string)
+	UE, _ := encrypt["UE"].(string)
+	if len(O) < 48 || len(U) < 48 || len(OE) != 32 || len(UE) != 32 {
+		return fmt.Errorf("malformed PDF: missing O=, U=, OE=, or UE= encryption parameters")
+	}
+	O, U = O[:48], U[:48]
+
+	pw, err := saslPrep(password)
+	if err != nil {
+		return err
+	}
+	if len(pw) > 127 {
+		pw = pw[:127]
+	}
+	hash := hashR6
+	if R == 5 {
+		hash = hashR5
+	}
+
+	var ikey []byte
+	var ekey string
+	switch {
+	case bytes.Equal(hash(pw, []byte(O[32:40]), []byte(U)), []byte(O[:32])):
+		ikey, ekey = hash(pw, []byte(O[40:48]), []byte(U)), OE
+	case bytes.Equal(hash(pw, []byte(U[32:40]), nil), []byte(U[:32])):
+		ikey, ekey = hash(pw, []byte(U[40:48]), nil), UE
+	default:
+		return ErrInvalidPassword
+	}
+	cb, err := aes.NewCipher(ikey)
+	if err != nil {
+		return err
+	}
+	key := []byte(ekey)
+	cipher.NewCBCDecrypter(cb, make([]byte, aes.BlockSize)).CryptBlocks(key, key)
+
+	// Perms repeats P and EncryptMetadata under the file key.
+	if perms, _ := encrypt["Perms"].(string); len(perms) == 16 {
+		cb, _ := aes.NewCipher(key)
+		p := make([]byte, 16)
+		cb.Decrypt(p, []byte(perms))
+		if string(p[9:12]) != "adb" {
+			return fmt.Errorf("malformed PDF: Perms does not match the file key")
+		}
+	}
+	c.key = key
+	return nil
+}
+
+// hashR5 is the password hash of revision 5.
+func hashR5(pw, salt, udata []byte) []byte {
+	h := sha256.New()
+	h.Write(pw)
+	h.Write(salt)
+	h.Write(udata)
+	return h.Sum(nil)
+}
+
+// hashR6 is the password hash of revision 6 (Algorithm 2.B).
+func hashR6(pw, salt, udata []byte) []byte {
+	k := hashR5(pw, salt, udata)
+	for i := 0; ; i++ {
+		var k1 []byte
+		for j := 0; j < 64; j++ {
+			k1 = append(k1, pw...)
+			k1 = append(k1, k...)
+			k1 = append(k1, udata...)
+		}
+		cb, _ := aes.NewCipher(k[:16])
+		e := k1
+		cipher.NewCBCEncrypter(cb, k[16:32]).CryptBlocks(e, k1)
+
+		sum := 0
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		var h hash.Hash
+		switch sum % 3 {
+		case 0:
+			h = sha256.New()
+		case 1:
+			h = sha512.New384()
+		case 2:
+			h = sha512.New()
+		}
+		h.Write(e)
+		k = h.Sum(nil)
+		if i >= 63 && int(e[len(e)-1]) <= i-31 {
+			break
+		}
+	}
+	return k[:32]
+}
+
+func cryptKey(key []byte, m cryptMethod, ptr objptr) []byte {
+	if m == cryptAESV3 {
+		return key
+	}
+	h := md5.New()
+	h.Write(key)
+	h.Write([]byte{byte(ptr.id), byte(ptr.id >> 8), byte(ptr.id >> 16), byte(ptr.gen), byte(ptr.gen >> 8)})
+	if m == cryptAESV2 {
+		h.Write([]byte("sAlT"))
+	}
+	n := len(key) + 5
+	if n > 16 {
+		n = 16
+	}
+	return h.Sum(nil)[:n]
+}
+
+func decryptString(key []byte, m cryptMethod, ptr objptr, x string) string {
+	switch m {
+	case cryptIdentity:
+		return x
+	case cryptRC4:
+		c, _ := rc4.NewCipher(cryptKey(key, m, ptr))
+		data := []byte(x)
+		c.XORKeyStream(data, data)
+		return string(data)
+	}
+	cb, err := aes.NewCipher(cryptKey(key, m, ptr))
+	if err != nil || len(x) < 2*aes.BlockSize || len(x)%aes.BlockSize != 0 {
+		return x
+	}
+	data := []byte(x[aes.BlockSize:])
+	cipher.NewCBCDecrypter(cb, []byte(x[:aes.BlockSize])).CryptBlocks(data, data)
+	return string(unpad(data))
+}
+
+// unpad removes PKCS#5 padding, leaving malformed data alone.
+func unpad(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > aes.BlockSize || n > len(data) {
+		return data
+	}
+	return data[:len(data)-n]
+}
+
+func decryptStream(key []byte, m cryptMethod, ptr objptr, rd io.Reader) io.Reader {
+	switch m {
+	case cryptIdentity:
+		return rd
+	case cryptRC4:
+		c, _ := rc4.NewCipher(cryptKey(key, m, ptr))
+		return &cipher.StreamReader{S: c, R: rd}
+	}
+	cb, err := aes.NewCipher(cryptKey(key, m, ptr))
+	if err != nil {
+		return &errorReadCloser{err}
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rd, iv); err != nil {
+		return &errorReadCloser{errors.New("encrypted stream too short")}
+	}
+	cbc := cipher.NewCBCDecrypter(cb, iv)
+	return &cbcReader{cbc: cbc, rd: rd, buf: make([]byte, aes.BlockSize)}
+}
+
+// A cbcReader decrypts AES-CBC data, removing the padding from the last block.
+type cbcReader struct {
+	cbc  cipher.BlockMode
+	rd   io.Reader
+	buf  []byte
+	last []byte // decrypted block held back until the next one is read
+	pend []byte
+	eof  bool
+}
+
+func (r *cbcReader) Read(b []byte) (n int, err error) {
+	for len(r.pend) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		_, err = io.ReadFull(r.rd, r.buf)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			r.eof = true
+			r.pend = unpad(r.last)
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		r.cbc.CryptBlocks(r.buf, r.buf)
+		prev := r.last
+		r.last, r.pend = r.buf, prev
+		if prev == nil {
+			prev = make([]byte, aes.BlockSize)
+		}
+		r.buf = prev
+	}
+	n = copy(b, r.pend)
+	r.pend = r.pend[n:]
+	return n, nil
+}
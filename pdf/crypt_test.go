@@ -0,0 +1,125 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The files in testdata are written by testdata/mkencrypted.go.
+var cryptTests = []struct {
+	file string
+	user string // user password; empty opens without asking
+}{
+	{"rc4-40.pdf", "user"},                         // V1 R2
+	{"rc4-128.pdf", "user"},                        // V2 R3
+	{"rc4-v4.pdf", "user"},                         // V4 with CFM V2
+	{"aes128.pdf", ""},                             // V4 with CFM AESV2
+	{"aes128-nometa.pdf", "user"},                  // EncryptMetadata false
+	{"aes128-strf-identity.pdf", ""},               // StrF Identity, stream with its own Identity filter
+	{"aes128-stmf-identity.pdf", "user"},           // StmF Identity
+	{"aes256-r5.pdf", "user"},                      // V5 R5, SHA-256 hash
+	{"aes256-r6.pdf", "user"},                      // V5 R6, Algorithm 2.B hash
+	{"aes256-r6-nometa.pdf", ""},                   // V5 with EncryptMetadata false
+	{"aes256-r6-saslprep.pdf", "pass\u30ab\u3099"}, // decomposed, stored as "passガ"
+}
+
+// openEncrypted opens file from testdata, answering password prompts
+// with passwords in turn.
+func openEncrypted(t *testing.T, file string, passwords ...string) (*Reader, error) {
+	f, err := os.Open(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewReaderEncrypted(f, fi.Size(), func() string {
+		if len(passwords) == 0 {
+			return ""
+		}
+		pw := passwords[0]
+		passwords = passwords[1:]
+		return pw
+	})
+}
+
+func TestEncrypted(t *testing.T) {
+	for _, tt := range cryptTests {
+		t.Run(tt.file, func(t *testing.T) {
+			for _, pw := range []string{tt.user, "owner"} {
+				who := "user"
+				if pw == "owner" {
+					who = "owner"
+				}
+				r, err := openEncrypted(t, tt.file, "wrong", pw)
+				if err != nil {
+					t.Fatalf("%s password %q: %v", who, pw, err)
+				}
+				text, err := r.Page(1).GetPlainText(nil)
+				if err != nil || !strings.Contains(text, "secret text") {
+					t.Errorf("%s password: page text = %q, %v; want secret text", who, text, err)
+				}
+				if title := r.Trailer().Key("Info").Key("Title").Text(); title != "Secret Title" {
+					t.Errorf("%s password: Title = %q, want Secret Title", who, title)
+				}
+				meta, err := io.ReadAll(r.Trailer().Key("Root").Key("Metadata").Reader())
+				if err != nil || !strings.Contains(string(meta), "plain metadata") {
+					t.Errorf("%s password: Metadata = %q, %v; want plain metadata", who, meta, err)
+				}
+			}
+
+			if tt.user == "" {
+				return
+			}
+			if _, err := openEncrypted(t, tt.file, "wrong", "User", "owner "); err != ErrInvalidPassword {
+				t.Errorf("wrong passwords: err = %v, want ErrInvalidPassword", err)
+			}
+			if _, err := openEncrypted(t, tt.file); err != ErrInvalidPassword {
+				t.Errorf("no password: err = %v, want ErrInvalidPassword", err)
+			}
+		})
+	}
+}
+
+// The examples of RFC 4013, section 3, and the normalizations that
+// matter for Japanese passwords.
+var saslPrepTests = []struct {
+	in, out string // out is empty when in is rejected
+}{
+	{"I\u00adX", "IX"},
+	{"user", "user"},
+	{"USER", "USER"},
+	{"ª", "a"},
+	{"Ⅸ", "IX"},
+	{"\u0007", ""},
+	{"ا1", ""},
+	{"ا1ب", "ا1ب"},
+	{"pass\u3000word", "pass word"},
+	{"ｐａｓｓ", "pass"},
+	{"ｶﾞｷﾞｸﾞ", "ガギグ"},
+	{"\u30ab\u3099", "ガ"},
+	{"\u1100\u1161\u11a8", "각"},
+	{"㈱", "(株)"},
+	{"", ""},
+}
+
+func TestSASLPrep(t *testing.T) {
+	for _, tt := range saslPrepTests {
+		out, err := saslPrep(tt.in)
+		switch {
+		case tt.out == "" && err == nil:
+			t.Errorf("saslPrep(%+q) = %+q, want error", tt.in, out)
+		case tt.out != "" && (err != nil || string(out) != tt.out):
+			t.Errorf("saslPrep(%+q) = %+q, %v, want %+q", tt.in, out, err, tt.out)
+		}
+	}
+}
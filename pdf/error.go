@@ -0,0 +1,96 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrClosed is the error reported when a Reader is used after Close.
+var ErrClosed = errors.New("use of closed Reader")
+
+// An Error describes a problem found while reading a PDF file.
+//
+// Obj and Gen identify the indirect object being read; Obj is 0 when the
+// problem is not inside an indirect object (for example, in the trailer).
+// Offset is the byte offset of the problem, or -1 if it is unknown.
+// If InStream is true, Offset counts bytes of the decoded data of
+// stream Obj (an object stream or a content stream) rather than bytes
+// of the file.
+type Error struct {
+	Obj      uint32
+	Gen      uint16
+	Offset   int64
+	InStream bool
+	Err      error
+}
+
+func (e *Error) Error() string {
+	s := "pdf"
+	if e.Obj != 0 {
+		s += fmt.Sprintf(": object %d %d", e.Obj, e.Gen)
+	}
+	if e.Offset >= 0 {
+		if e.InStream {
+			s += fmt.Sprintf(": stream offset %d", e.Offset)
+		} else {
+			s += fmt.Sprintf(": offset %d", e.Offset)
+		}
+	}
+	return s + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(ptr objptr, offset int64, format string, args ...interface{}) *Error {
+	return &Error{Obj: ptr.id, Gen: ptr.gen, Offset: offset, Err: fmt.Errorf(format, args...)}
+}
+
+// wrapError attaches ptr and offset to err unless err already carries them.
+func wrapError(ptr objptr, offset int64, err error) error {
+	var e *Error
+	if err == nil || errors.As(err, &e) {
+		return err
+	}
+	return &Error{Obj: ptr.id, Gen: ptr.gen, Offset: offset, Err: err}
+}
+
+// SetErrorHandler sets a function to be called with each error found while
+// traversing the file through the Value API. The accessors on Value keep
+// returning zero results for malformed data; the handler lets callers see why.
+// Errors are always *Error values.
+func (r *Reader) SetErrorHandler(fn func(error)) {
+	r.errh = fn
+}
+
+// Err returns the first error found while traversing the file through the
+// Value API, or nil if there has been none.
+func (r *Reader) Err() error {
+	if r == nil {
+		return nil
+	}
+	return r.err
+}
+
+// report records err and passes it to the error handler.
+func (r *Reader) report(err error) {
+	if r == nil || err == nil {
+		return
+	}
+	if r.err == nil {
+		r.err = err
+	}
+	if r.errh != nil {
+		r.errh(err)
+	}
+}
+
+// errorf reports an error found in the object ptr at the given offset.
+func (r *Reader) errorf(ptr objptr, offset int64, format string, args ...interface{}) {
+	r.report(newError(ptr, offset, format, args...))
+}
@@ -0,0 +1,499 @@
+// Stream filters (PDF 32000-1:2008, §7.4) and predictors.
+
+package pdf
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/ascii85"
+	"fmt"
+	"io"
+)
+
+// applyFilter returns a reader that decodes rd with the named filter.
+// Abbreviated names from inline images are accepted too.
+func applyFilter(rd io.Reader, name string, param Value) (io.Reader, error) {
+	switch name {
+	default:
+		return nil, fmt.Errorf("unknown filter %s", name)
+	case "FlateDecode", "Fl":
+		zr, err := zlib.NewReader(rd)
+		if err != nil {
+			return nil, err
+		}
+		return applyPredictor(zr, param)
+	case "LZWDecode", "LZW":
+		early := 1
+		if v := param.Key("EarlyChange"); v.Kind() == Integer {
+			early = int(v.Int64())
+		}
+		if early != 0 && early != 1 {
+			return nil, fmt.Errorf("invalid EarlyChange %d", early)
+		}
+		return applyPredictor(newLZWReader(rd, early), param)
+	case "ASCII85Decode", "A85":
+		cleanASCII85 := newAlphaReader(rd)
+		decoder := ascii85.NewDecoder(cleanASCII85)
+
+		switch param.Keys() {
+		default:
+			return nil, fmt.Errorf("unexpected DecodeParms for ASCII85Decode: %v", param)
+		case nil:
+			return decoder, nil
+		}
+	case "ASCIIHexDecode", "AHx":
+		return &hexReader{r: bufio.NewReader(rd)}, nil
+	case "RunLengthDecode", "RL":
+		return &runLengthReader{r: bufio.NewReader(rd)}, nil
+	case "Crypt":
+		// Decryption is done before the filters are applied; see crypt.streamMethod.
+		return rd, nil
+	case "DCTDecode", "DCT", "JPXDecode", "CCITTFaxDecode", "CCF", "JBIG2Decode":
+		// Image data is returned still encoded, for the caller to decode.
+		return rd, nil
+	}
+}
+
+type alphaReader struct {
+	reader io.Reader
+}
+
+func newAlphaReader(reader io.Reader) *alphaReader {
+	return &alphaReader{reader: reader}
+}
+
+func checkASCII85(r byte) byte {
+	if r >= '!' && r <= 'u' { // 33 <= ascii85 <=117
+		return r
+	}
+	if r == '~' {
+		return 1 // for marking possible end of data
+	}
+	return 0 // if non-ascii85
+}
+
+func (a *alphaReader) Read(p []byte) (int, error) {
+	n, err := a.reader.Read(p)
+	if err == io.EOF {
+	}
+	if err != nil {
+		return n, err
+	}
+	buf := make([]byte, n)
+	tilda := false
+	for i := 0; i < n; i++ {
+		char := checkASCII85(p[i])
+		if char == '>' && tilda { // end of data
+			break
+		}
+		if char > 1 {
+			buf[i] = char
+		}
+		if char == 1 {
+			tilda = true // possible end of data
+		}
+	}
+
+	copy(p, buf)
+	return n, nil
+}
+
+// A hexReader decodes ASCIIHexDecode data.
+type hexReader struct {
+	r   *bufio.Reader
+	eod bool
+}
+
+func (h *hexReader) Read(b []byte) (int, error) {
+	n := 0
+	for n < len(b) && !h.eod {
+		var x [2]int
+		i := 0
+		for i < 2 {
+			c, err := h.r.ReadByte()
+			if err == io.EOF || err == nil && c == '>' {
+				h.eod = true
+				break
+			}
+			if err != nil {
+				return n, err
+			}
+			if isSpace(c) {
+				continue
+			}
+			x[i] = unhex(c)
+			if x[i] < 0 {
+				return n, fmt.Errorf("malformed ASCIIHexDecode data %q", c)
+			}
+			i++
+		}
+		if i == 0 {
+			break
+		}
+		// An odd final digit is followed by an implied 0.
+		b[n] = byte(x[0]<<4 | x[1])
+		n++
+	}
+	if n == 0 && h.eod {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// A runLengthReader decodes RunLengthDecode data.
+type runLengthReader struct {
+	r   *bufio.Reader
+	lit int  // literal bytes left to copy
+	rep int  // times left to repeat c
+	c   byte // byte being repeated
+	eod bool
+}
+
+func (r *runLengthReader) Read(b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		switch {
+		case r.lit > 0:
+			c, err := r.r.ReadByte()
+			if err != nil {
+				return n, io.ErrUnexpectedEOF
+			}
+			b[n] = c
+			n++
+			r.lit--
+			continue
+		case r.rep > 0:
+			b[n] = r.c
+			n++
+			r.rep--
+			continue
+		case r.eod:
+			if n == 0 {
+				return 0, io.EOF
+			}
+			return n, nil
+		}
+		length, err := r.r.ReadByte()
+		if err == io.EOF || err == nil && length == 128 {
+			r.eod = true
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		if length < 128 {
+			r.lit = int(length) + 1
+			continue
+		}
+		r.c, err = r.r.ReadByte()
+		if err != nil {
+			return n, io.ErrUnexpectedEOF
+		}
+		r.rep = 257 - int(length)
+	}
+	return n, nil
+}
+
+// An lzwReader decodes LZWDecode data: MSB-first codes of 9 to 12 bits
+// with 256 as the clear code and 257 as end of data. With early set to 1
+// (the default in PDF) the code width grows one code earlier than in GIF.
+type lzwReader struct {
+	r      *bufio.Reader
+	early  int
+	bits   uint32
+	nbits  uint
+	width  uint
+	next   int // next code to assign
+	prev   int // previous code, or -1 after a clear code
+	prefix [4096]uint16
+	suffix [4096]byte
+	length [4096]uint16
+	out    []byte // decoded but not yet returned
+	buf    []byte
+	err    error
+}
+
+func newLZWReader(rd io.Reader, early int) *lzwReader {
+	l := &lzwReader{r: bufio.NewReader(rd), early: early}
+	for i := 0; i < 256; i++ {
+		l.suffix[i] = byte(i)
+		l.length[i] = 1
+	}
+	l.clear()
+	return l
+}
+
+func (l *lzwReader) clear() {
+	l.width = 9
+	l.next = 258
+	l.prev = -1
+}
+
+func (l *lzwReader) readCode() (int, error) {
+	for l.nbits < l.width {
+		c, err := l.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		l.bits = l.bits<<8 | uint32(c)
+		l.nbits += 8
+	}
+	code := int(l.bits>>(l.nbits-l.width)) & (1<<l.width - 1)
+	l.nbits -= l.width
+	return code, nil
+}
+
+// entry returns the string for code, written into l.buf.
+func (l *lzwReader) entry(code int) []byte {
+	n := int(l.length[code])
+	if cap(l.buf) < n {
+		l.buf = make([]byte, n, 4096)
+	}
+	l.buf = l.buf[:n]
+	for i := n - 1; i >= 0; i-- {
+		l.buf[i] = l.suffix[code]
+		code = int(l.prefix[code])
+	}
+	return l.buf
+}
+
+func (l *lzwReader) Read(b []byte) (int, error) {
+	for len(l.out) == 0 {
+		if l.err != nil {
+			return 0, l.err
+		}
+		code, err := l.readCode()
+		if err == io.EOF {
+			// Missing end-of-data code; accept what we have.
+			l.err = io.EOF
+			continue
+		}
+		if err != nil {
+			l.err = err
+			continue
+		}
+		switch {
+		case code == 256:
+			l.clear()
+			continue
+		case code == 257:
+			l.err = io.EOF
+			continue
+		case l.prev < 0:
+			if code > 255 {
+				l.err = fmt.Errorf("malformed LZW data: code %d after clear", code)
+				continue
+			}
+			l.out = l.entry(code)
+		case code < l.next:
+			l.out = l.entry(code)
+			l.add(l.prev, l.out[0])
+		case code == l.next:
+			first := l.entry(l.prev)[0]
+			l.add(l.prev, first)
+			l.out = l.entry(code)
+		default:
+			l.err = fmt.Errorf("malformed LZW data: code %d beyond table size %d", code, l.next)
+			continue
+		}
+		l.prev = code
+	}
+	n := copy(b, l.out)
+	l.out = l.out[n:]
+	return n, nil
+}
+
+func (l *lzwReader) add(prefix int, c byte) {
+	if l.next >= len(l.prefix) {
+		return
+	}
+	l.prefix[l.next] = uint16(prefix)
+	l.suffix[l.next] = c
+	l.length[l.next] = l.length[prefix] + 1
+	l.next++
+	if l.next+l.early >= 1<<l.width && l.width < 12 {
+		l.width++
+	}
+}
+
+// applyPredictor undoes the predictor named in param, if any.
+func applyPredictor(rd io.Reader, param Value) (io.Reader, error) {
+	pred := param.Key("Predictor")
+	if pred.Kind() == Null || pred.Int64() == 1 {
+		return rd, nil
+	}
+	colors, bpc, columns := int64(1), int64(8), int64(1)
+	if v := param.Key("Colors"); v.Kind() == Integer {
+		colors = v.Int64()
+	}
+	if v := param.Key("BitsPerComponent"); v.Kind() == Integer {
+		bpc = v.Int64()
+	}
+	if v := param.Key("Columns"); v.Kind() == Integer {
+		columns = v.Int64()
+	}
+	switch bpc {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, fmt.Errorf("invalid BitsPerComponent %d", bpc)
+	}
+	if colors < 1 || colors > 32 || columns < 1 || columns > 1<<20 {
+		return nil, fmt.Errorf("invalid predictor Colors %d or Columns %d", colors, columns)
+	}
+	p := &predictorReader{
+		r:      rd,
+		bpc:    int(bpc),
+		colors: int(colors),
+		rowlen: int((colors*bpc*columns + 7) / 8),
+		bpp:    int((colors*bpc + 7) / 8),
+	}
+	switch n := pred.Int64(); {
+	case n == 2:
+		p.tiff = true
+	case n >= 10 && n <= 15:
+		p.rowlen++ // each row starts with its PNG filter type
+	default:
+		return nil, fmt.Errorf("unknown predictor %d", n)
+	}
+	p.prev = make([]byte, p.rowlen)
+	p.cur = make([]byte, p.rowlen)
+	return p, nil
+}
+
+// A predictorReader undoes TIFF predictor 2 or the PNG predictors,
+// one row at a time.
+type predictorReader struct {
+	r      io.Reader
+	tiff   bool
+	bpc    int
+	colors int
+	rowlen int // bytes per row, including the PNG filter type byte
+	bpp    int // bytes per complete pixel, at least 1
+	prev   []byte
+	cur    []byte
+	pend   []byte
+}
+
+func (p *predictorReader) Read(b []byte) (int, error) {
+	n := 0
+	for len(b) > 0 {
+		if len(p.pend) > 0 {
+			m := copy(b, p.pend)
+			n += m
+			b = b[m:]
+			p.pend = p.pend[m:]
+			continue
+		}
+		if n > 0 {
+			break
+		}
+		m, err := io.ReadFull(p.r, p.cur)
+		if err == io.ErrUnexpectedEOF && m > 0 {
+			// Short final row; decode what is there.
+			p.cur = p.cur[:m]
+			err = nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if p.tiff {
+			p.undoTIFF(p.cur)
+			p.pend = p.cur
+		} else {
+			if err := p.undoPNG(); err != nil {
+				return n, err
+			}
+			p.pend = p.cur[1:]
+		}
+		p.prev, p.cur = p.cur, p.prev[:cap(p.prev)]
+	}
+	return n, nil
+}
+
+func (p *predictorReader) undoPNG() error {
+	cur, prev := p.cur[1:], p.prev[1:len(p.cur)]
+	bpp := p.bpp
+	switch p.cur[0] {
+	case 0: // None
+	case 1: // Sub
+		for i := bpp; i < len(cur); i++ {
+			cur[i] += cur[i-bpp]
+		}
+	case 2: // Up
+		for i := range cur {
+			cur[i] += prev[i]
+		}
+	case 3: // Average
+		for i := range cur {
+			var left int
+			if i >= bpp {
+				left = int(cur[i-bpp])
+			}
+			cur[i] += byte((left + int(prev[i])) / 2)
+		}
+	case 4: // Paeth
+		for i := range cur {
+			var left, upleft byte
+			if i >= bpp {
+				left, upleft = cur[i-bpp], prev[i-bpp]
+			}
+			cur[i] += paeth(left, prev[i], upleft)
+		}
+	default:
+		return fmt.Errorf("malformed PNG predictor row type %d", p.cur[0])
+	}
+	return nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// undoTIFF undoes TIFF predictor 2: each component is stored as the
+// difference from the same component of the pixel to its left.
+func (p *predictorReader) undoTIFF(row []byte) {
+	switch p.bpc {
+	case 8:
+		for i := p.colors; i < len(row); i++ {
+			row[i] += row[i-p.colors]
+		}
+	case 16:
+		for i := 2 * p.colors; i+1 < len(row); i += 2 {
+			v := uint16(row[i])<<8 | uint16(row[i+1])
+			v += uint16(row[i-2*p.colors])<<8 | uint16(row[i+1-2*p.colors])
+			row[i], row[i+1] = byte(v>>8), byte(v)
+		}
+	default:
+		// Components smaller than a byte, MSB first.
+		bpc := uint(p.bpc)
+		mask := byte(1<<bpc - 1)
+		get := func(k int) byte {
+			shift := 8 - bpc - uint(k%(8/p.bpc))*bpc
+			return row[k/(8/p.bpc)] >> shift & mask
+		}
+		set := func(k int, v byte) {
+			shift := 8 - bpc - uint(k%(8/p.bpc))*bpc
+			i := k / (8 / p.bpc)
+			row[i] = row[i]&^(mask<<shift) | (v&mask)<<shift
+		}
+		n := len(row) * 8 / p.bpc
+		for k := p.colors; k < n; k++ {
+			set(k, get(k)+get(k-p.colors))
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package pdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A GaijiMap maps characters that a document renders as gaiji (glyphs
+// outside the standard character sets) to standard Unicode text. The
+// replacement may be an ideographic variation sequence.
+type GaijiMap struct {
+	// PUA maps Private Use Area code points produced by a font's
+	// ToUnicode CMap.
+	PUA map[rune]string
+
+	// CID maps character codes that have no usable ToUnicode entry.
+	// The outer key is the font's BaseFont; the empty key applies to
+	// every font.
+	CID map[string]map[int]string
+}
+
+// SetGaijiMap sets the gaiji table used when decoding text.
+// It must be called before any text is extracted.
+// Passing nil restores the default, which leaves PUA code points
+// in place and decodes unmapped codes as U+FFFD.
+func (r *Reader) SetGaijiMap(m *GaijiMap) {
+	r.gaiji = m
+}
+
+// IsPUA reports whether r is a Private Use Area code point.
+func IsPUA(r rune) bool {
+	return r >= 0xE000 && r <= 0xF8FF || r >= 0xF0000 && r <= 0xFFFFD || r >= 0x100000 && r <= 0x10FFFD
+}
+
+// ReadGaijiMap reads a gaiji table. Each non-empty line that does not
+// start with # has a source and a replacement separated by a tab:
+//
+//	U+E001	U+9AD9
+//	CID+1234	髙
+//	MS-Mincho/CID+1234	U+845B U+E0100
+//
+// A source is a PUA code point (U+XXXX) or a character code (CID+N,
+// decimal), optionally qualified by a BaseFont. A replacement is either
+// literal text or a space-separated list of U+XXXX code points, which
+// allows ideographic variation sequences.
+func ReadGaijiMap(rd io.Reader) (*GaijiMap, error) {
+	m := &GaijiMap{PUA: make(map[rune]string), CID: make(map[string]map[int]string)}
+	sc := bufio.NewScanner(rd)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		src, dst, ok := strings.Cut(text, "\t")
+		if !ok {
+			return nil, fmt.Errorf("gaiji map line %d: missing tab", line)
+		}
+		repl, err := parseGaijiReplacement(strings.TrimSpace(dst))
+		if err != nil {
+			return nil, fmt.Errorf("gaiji map line %d: %v", line, err)
+		}
+		font, key := "", strings.TrimSpace(src)
+		if i := strings.LastIndex(key, "/"); i >= 0 {
+			font, key = key[:i], key[i+1:]
+		}
+		switch {
+		case strings.HasPrefix(key, "U+") && font == "":
+			n, err := strconv.ParseUint(key[2:], 16, 32)
+			if err != nil || !IsPUA(rune(n)) {
+				return nil, fmt.Errorf("gaiji map line %d: %q is not a PUA code point", line, key)
+			}
+			m.PUA[rune(n)] = repl
+		case strings.HasPrefix(key, "CID+"):
+			n, err := strconv.Atoi(key[4:])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("gaiji map line %d: bad character code %q", line, key)
+			}
+			if m.CID[font] == nil {
+				m.CID[font] = make(map[int]string)
+			}
+			m.CID[font][n] = repl
+		default:
+			return nil, fmt.Errorf("gaiji map line %d: bad source %q", line, src)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func parseGaijiReplacement(s string) (string, error) {
+	if !strings.HasPrefix(s, "U+") {
+		if s == "" {
+			return "", fmt.Errorf("empty replacement")
+		}
+		return s, nil
+	}
+	var b strings.Builder
+	for _, f := range strings.Fields(s) {
+		n, err := strconv.ParseUint(strings.TrimPrefix(f, "U+"), 16, 32)
+		if err != nil || !strings.HasPrefix(f, "U+") {
+			return "", fmt.Errorf("bad code point %q", f)
+		}
+		b.WriteRune(rune(n))
+	}
+	return b.String(), nil
+}
+
+func (m *GaijiMap) lookupCID(font, code string) (string, bool) {
+	if m == nil || m.CID == nil {
+		return "", false
+	}
+	n := codeInt(code)
+	if s, ok := m.CID[font][n]; ok {
+		return s, true
+	}
+	s, ok := m.CID[""][n]
+	return s, ok
+}
+
+func (m *GaijiMap) replacePUA(s string) string {
+	if m == nil || len(m.PUA) == 0 {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if repl, ok := m.PUA[r]; ok && IsPUA(r) {
+			b.WriteString(repl)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// gaijiEncoder applies the PUA part of a GaijiMap to the text decoded
+// by the font's own encoding.
+type gaijiEncoder struct {
+	enc   TextEncoding
+	gaiji *GaijiMap
+}
+
+func (e *gaijiEncoder) Decode(raw string) string {
+	return e.gaiji.replacePUA(e.enc.Decode(raw))
+}
@@ -7,7 +7,6 @@
 package pdf
 
 import (
-	"errors"
 	"fmt"
 	"io"
 	"strconv"
@@ -44,9 +43,9 @@ type buffer struct {
 	allowObjptr bool
 	allowStream bool
 	eof         bool
-	key         []byte
-	useAES      bool
+	crypt       *crypt // decrypts strings of indirect objects
 	objptr      objptr
+	err         error // first syntax error, see errorf
 }
 
 // newBuffer returns a new buffer reading from r at the given offset.
@@ -79,9 +78,14 @@ func (b *buffer) readByte() byte {
 	return c
 }
 
-func (b *buffer) errorf(format string, args ...interface{}) string {
-	// panic(fmt.Errorf(format, args...))
-	return fmt.Sprintf(format, args...)
+// errorf records a syntax error at the current offset. Parsing continues
+// where it can; the first error is kept in b.err.
+func (b *buffer) errorf(format string, args ...interface{}) error {
+	err := &Error{Obj: b.objptr.id, Gen: b.objptr.gen, Offset: b.readOffset(), Err: fmt.Errorf(format, args...)}
+	if b.err == nil {
+		b.err = err
+	}
+	return err
 }
 
 func (b *buffer) reload() (bool, error) {
@@ -90,11 +94,11 @@ func (b *buffer) reload() (bool, error) {
 	if n == 0 && err != nil {
 		b.buf = b.buf[:0]
 		b.pos = 0
+		b.eof = true
 		if b.allowEOF && err == io.EOF {
-			b.eof = true
 			return false, err
 		}
-		fmt.Sprint(b.errorf("malformed PDF: reading at offset %d: %v", b.offset, err))
+		b.errorf("reading: %v", err)
 		return false, err
 	}
 	b.offset += int64(n)
@@ -106,9 +110,6 @@ func (b *buffer) reload() (bool, error) {
 func (b *buffer) seekForward(offset int64) (err error) {
 	for b.offset < offset {
 		rel, err := b.reload()
-		if err != nil {
-			return err
-		}
 		if !rel {
 			return err
 		}
@@ -181,15 +182,48 @@ func (b *buffer) readToken() token {
 
 	default:
 		if isDelim(c) {
-			// b.errorf("unexpected delimiter %#q", rune(c))
-			return b.errorf("unexpected delimiter %#q", rune(c))
-			// return nil
+			b.errorf("unexpected delimiter %#q", rune(c))
+			return b.readToken()
 		}
 		b.unreadByte()
 		return b.readKeyword()
 	}
 }
 
+// skipInlineImage skips the data of an inline image, which starts after
+// the single white-space character following ID, and the EI that ends it.
+// If n >= 0, the data is n bytes long. Otherwise the end is the first EI
+// with white space before it and white space or a delimiter after it,
+// which is what other readers look for too.
+func (b *buffer) skipInlineImage(n int64) {
+	w2 := b.readByte()
+	if n >= 0 {
+		for i := int64(0); i < n && !b.eof; i++ {
+			b.readByte()
+		}
+		if tok := b.readToken(); tok != keyword("EI") {
+			b.errorf("inline image data not followed by EI")
+			b.unreadToken(tok)
+		}
+		return
+	}
+	var w0, w1 byte
+	for {
+		c := b.readByte()
+		if isSpace(w0) && w1 == 'E' && w2 == 'I' && (b.eof || isSpace(c) || isDelim(c)) {
+			if isDelim(c) {
+				b.unreadByte()
+			}
+			return
+		}
+		if b.eof {
+			b.errorf("inline image without EI")
+			return
+		}
+		w0, w1, w2 = w1, w2, c
+	}
+}
+
 func (b *buffer) readHexString() token {
 	tmp := b.tmp[:0]
 	for {
@@ -198,6 +232,10 @@ func (b *buffer) readHexString() token {
 		if c == '>' {
 			break
 		}
+		if b.eof {
+			b.errorf("unterminated hex string")
+			break
+		}
 		if isSpace(c) {
 			goto Loop
 		}
@@ -208,7 +246,7 @@ func (b *buffer) readHexString() token {
 		}
 		x := unhex(c)<<4 | unhex(c2)
 		if x < 0 {
-			fmt.Sprint(b.errorf("malformed hex string %c %c %s", c, c2, b.buf[b.pos:]))
+			b.errorf("malformed hex string %c %c %s", c, c2, b.buf[b.pos:])
 			break
 		}
 		tmp = append(tmp, byte(x))
@@ -235,6 +273,10 @@ func (b *buffer) readLiteralString() token {
 Loop:
 	for {
 		c := b.readByte()
+		if b.eof {
+			b.errorf("unterminated literal string")
+			break
+		}
 		switch c {
 		default:
 			tmp = append(tmp, c)
@@ -249,8 +291,7 @@ Loop:
 		case '\\':
 			switch c = b.readByte(); c {
 			default:
-				// b.errorf("invalid escape sequence \\%c", c)
-				fmt.Sprint(b.errorf("invalid escape sequence \\%c", c))
+				b.errorf("invalid escape sequence \\%c", c)
 				tmp = append(tmp, '\\', c)
 			case 'n':
 				tmp = append(tmp, '\n')
@@ -303,8 +344,7 @@ func (b *buffer) readName() token {
 		if c == '#' {
 			x := unhex(b.readByte())<<4 | unhex(b.readByte())
 			if x < 0 {
-				// b.errorf("malformed name")
-				fmt.Sprint(b.errorf("malformed name"))
+				b.errorf("malformed name")
 			}
 			tmp = append(tmp, byte(x))
 			continue
@@ -335,15 +375,13 @@ func (b *buffer) readKeyword() token {
 	case isInteger(s):
 		x, err := strconv.ParseInt(s, 10, 64)
 		if err != nil {
-			// b.errorf("invalid integer %s", s)
-			fmt.Sprint(b.errorf("invalid integer %s", s))
+			b.errorf("invalid integer %s", s)
 		}
 		return x
 	case isReal(s):
 		x, err := strconv.ParseFloat(s, 64)
 		if err != nil {
-			// b.errorf("invalid real %s", s)
-			fmt.Sprint(b.errorf("invalid real %s", s))
+			b.errorf("invalid real %s", s)
 		}
 		return x
 	}
@@ -432,13 +470,11 @@ func (b *buffer) readObject() (object, error) {
 		case "[":
 			return b.readArray(), nil
 		}
-		// b.errorf("unexpected keyword %q parsing object", kw)
-		return nil, errors.New(b.errorf("unexpected keyword %q parsing object", kw))
-		// return nil
+		return nil, b.errorf("unexpected keyword %q parsing object", kw)
 	}
 
-	if str, ok := tok.(string); ok && b.key != nil && b.objptr.id != 0 {
-		tok = decryptString(b.key, b.useAES, b.objptr, str)
+	if str, ok := tok.(string); ok && b.crypt != nil && b.objptr.id != 0 {
+		tok = decryptString(b.crypt.key, b.crypt.strf, b.objptr, str)
 	}
 
 	if !b.allowObjptr {
@@ -462,8 +498,7 @@ func (b *buffer) readObject() (object, error) {
 				if _, ok := obj.(stream); !ok {
 					tok4 := b.readToken()
 					if tok4 != keyword("endobj") {
-						// b.errorf("missing endobj after indirect object definition")
-						fmt.Sprint(b.errorf("missing endobj after indirect object definition"))
+						b.errorf("missing endobj after indirect object definition")
 						b.unreadToken(tok4)
 					}
 				}
@@ -484,10 +519,14 @@ func (b *buffer) readArray() object {
 		if tok == nil || tok == keyword("]") {
 			break
 		}
+		if tok == io.EOF {
+			b.errorf("unexpected EOF in array")
+			break
+		}
 		b.unreadToken(tok)
 		res, err := b.readObject()
 		if err != nil {
-			return err
+			break
 		}
 		x = append(x, res)
 	}
@@ -501,10 +540,13 @@ func (b *buffer) readDict() object {
 		if tok == nil || tok == keyword(">>") {
 			break
 		}
+		if tok == io.EOF {
+			b.errorf("unexpected EOF in dictionary")
+			break
+		}
 		n, ok := tok.(name)
 		if !ok {
-			// b.errorf("unexpected non-name key %T(%v) parsing dictionary", tok, tok)
-			fmt.Sprint(b.errorf("unexpected non-name key %T(%v) parsing dictionary", tok, tok))
+			b.errorf("unexpected non-name key %T(%v) parsing dictionary", tok, tok)
 			continue
 		}
 		res, err := b.readObject()
@@ -532,8 +574,8 @@ func (b *buffer) readDict() object {
 	case '\n':
 		// ok
 	default:
-		// b.errorf("stream keyword not followed by newline")
-		return b.errorf("stream keyword not followed by newline")
+		b.errorf("stream keyword not followed by newline")
+		b.unreadByte()
 	}
 
 	return stream{x, b.objptr, b.readOffset()}
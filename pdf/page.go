@@ -23,44 +23,56 @@ type Page struct {
 // Page numbers are indexed starting at 1, not 0.
 // If the page is not found, Page returns a Page with p.V.IsNull().
 func (r *Reader) Page(num int) Page {
-	num-- // now 0-indexed
-	page := r.Trailer().Key("Root").Key("Pages")
-Search:
-	for page.Key("Type").Name() == "Pages" {
-		count := int(page.Key("Count").Int64())
-		if count < num {
-			return Page{}
-		}
-		kids := page.Key("Kids")
-		for i := 0; i < kids.Len(); i++ {
-			kid := kids.Index(i)
-			if kid.Key("Type").Name() == "Pages" {
-				c := int(kid.Key("Count").Int64())
-				if num < c {
-					page = kid
-					continue Search
-				}
-				num -= c
-				continue
-			}
-			if kid.Key("Type").Name() == "Page" {
-				if num == 0 {
-					return Page{kid}
-				}
-				num--
-			}
-		}
+	if r.closed {
+		r.report(&Error{Offset: -1, Err: ErrClosed})
+		return Page{}
+	}
+	pages := r.pageIndex()
+	if num < 1 || num > len(pages) {
+		return Page{}
 	}
-	return Page{}
+	return Page{pages[num-1]}
 }
 
 // NumPage returns the number of pages in the PDF file.
 func (r *Reader) NumPage() int {
-	return int(r.Trailer().Key("Root").Key("Pages").Key("Count").Int64())
+	return len(r.pageIndex())
+}
+
+// pageIndex returns the leaves of the page tree in order.
+// The tree is walked once, on first use.
+func (r *Reader) pageIndex() []Value {
+	r.pagesOnce.Do(func() {
+		seen := make(map[objptr]bool)
+		var walk func(node Value)
+		walk = func(node Value) {
+			if node.ptr != (objptr{}) {
+				if seen[node.ptr] {
+					r.errorf(node.ptr, -1, "page tree loop")
+					return
+				}
+				seen[node.ptr] = true
+			}
+			switch node.Key("Type").Name() {
+			case "Pages":
+				kids := node.Key("Kids")
+				for i := 0; i < kids.Len(); i++ {
+					walk(kids.Index(i))
+				}
+			case "Page":
+				r.pages = append(r.pages, node)
+			}
+		}
+		walk(r.Trailer().Key("Root").Key("Pages"))
+	})
+	return r.pages
 }
 
 // GetPlainText returns all the text in the PDF file
 func (r *Reader) GetPlainText() (reader io.Reader, err error) {
+	if r.closed {
+		return &bytes.Buffer{}, &Error{Offset: -1, Err: ErrClosed}
+	}
 	pages := r.NumPage()
 	var buf bytes.Buffer
 	fonts := make(map[string]*Font)
@@ -148,7 +160,12 @@ func (f Font) Widths() []float64 {
 }
 
 // Width returns the width of the given code point.
+// For a composite (Type0) font, code is a CID and the width comes from
+// the descendant font's /W and /DW entries.
 func (f Font) Width(code int) float64 {
+	if f.V.Key("Subtype").Name() == "Type0" {
+		return readCIDMetrics(f).width(code)
+	}
 	first := f.FirstChar()
 	last := f.LastChar()
 	if code < first || last < code {
@@ -166,6 +183,14 @@ func (f Font) Encoder() TextEncoding {
 }
 
 func (f Font) getEncoder() TextEncoding {
+	enc := f.baseEncoder()
+	if f.V.r != nil && f.V.r.gaiji != nil {
+		return &gaijiEncoder{enc, f.V.r.gaiji}
+	}
+	return enc
+}
+
+func (f Font) baseEncoder() TextEncoding {
 	enc := f.V.Key("Encoding")
 	switch enc.Kind() {
 	case Name:
@@ -177,10 +202,18 @@ func (f Font) getEncoder() TextEncoding {
 		case "Identity-H":
 			return f.charmapEncoding()
 		default:
+			if f.V.Key("Subtype").Name() == "Type0" {
+				return f.charmapEncoding()
+			}
 			return &nopEncoder{}
 		}
 	case Dict:
 		return &dictEncoder{enc.Key("Differences")}
+	case Stream:
+		if f.V.Key("Subtype").Name() == "Type0" {
+			return f.charmapEncoding()
+		}
+		return &nopEncoder{}
 	case Null:
 		return f.charmapEncoding()
 	default:
@@ -195,8 +228,18 @@ func (f *Font) charmapEncoding() TextEncoding {
 		if m == nil {
 			return &nopEncoder{}
 		}
+		if f.V.r != nil {
+			m.gaiji = f.V.r.gaiji
+			m.font = f.BaseFont()
+		}
 		return m
 	}
+	if f.V.Key("Subtype").Name() == "Type0" {
+		if enc := f.cidEncoding(); enc != nil {
+			return enc
+		}
+		return &nopEncoder{}
+	}
 
 	return &byteEncoder{&pdfDocEncoding}
 }
@@ -275,10 +318,82 @@ type bfrange struct {
 	dst Value
 }
 
+// A cidRange maps the codes lo through hi to consecutive CIDs from cid.
+// A cidchar entry is a cidRange with lo == hi.
+type cidRange struct {
+	lo  string
+	hi  string
+	cid int
+}
+
 type cmap struct {
-	space   [4][]byteRange // codespace range
-	bfrange []bfrange
-	bfchar  []bfchar
+	space    [4][]byteRange // codespace range
+	bfrange  []bfrange
+	bfchar   []bfchar
+	cidrange []cidRange // in a CMap used as a Type0 font's Encoding
+	usecmap  string     // name of the CMap this one extends
+	gaiji    *GaijiMap  // consulted for codes the CMap does not map
+	font     string
+}
+
+// hasCodespace reports whether the CMap defines any codespace range.
+func (m *cmap) hasCodespace() bool {
+	for _, s := range m.space {
+		if len(s) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// codeLen returns the length of the first code in raw according to the
+// codespace ranges, or 0 if raw does not start with a valid code.
+func (m *cmap) codeLen(raw string) int {
+	for n := 1; n <= 4 && n <= len(raw); n++ {
+		for _, space := range m.space[n-1] {
+			if space.low <= raw[:n] && raw[:n] <= space.high {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// cid returns the CID that code selects, or -1 if the CMap does not map it.
+func (m *cmap) cid(code string) int {
+	for _, r := range m.cidrange {
+		if len(r.lo) == len(code) && r.lo <= code && code <= r.hi {
+			return r.cid + codeInt(code) - codeInt(r.lo)
+		}
+	}
+	return -1
+}
+
+// codeInt returns the big-endian value of a character code.
+func codeInt(code string) int {
+	n := 0
+	for i := 0; i < len(code); i++ {
+		n = n<<8 | int(code[i])
+	}
+	return n
+}
+
+// unmapped returns the text for a code with no usable ToUnicode entry:
+// the gaiji table's replacement if there is one, noRune otherwise.
+func (m *cmap) unmapped(code string) []rune {
+	if s, ok := m.gaiji.lookupCID(m.font, code); ok {
+		return []rune(s)
+	}
+	return []rune{noRune}
+}
+
+// decodeRepl decodes a UTF-16 replacement; an empty one is a glyph that
+// would otherwise vanish from the text, so it is reported as unmapped.
+func (m *cmap) decodeRepl(code, repl string) []rune {
+	if r := []rune(utf16Decode(repl)); len(r) > 0 {
+		return r
+	}
+	return m.unmapped(code)
 }
 
 func (m *cmap) Decode(raw string) (text string) {
@@ -292,7 +407,7 @@ Parse:
 					raw = raw[n:]
 					for _, bfchar := range m.bfchar { // check for matching bfchar
 						if len(bfchar.orig) == n && bfchar.orig == text {
-							r = append(r, []rune(utf16Decode(bfchar.repl))...)
+							r = append(r, m.decodeRepl(text, bfchar.repl)...)
 							continue Parse
 						}
 					}
@@ -305,7 +420,7 @@ Parse:
 									b[len(b)-1] += text[len(text)-1] - bfrange.lo[len(bfrange.lo)-1] // increment last byte by difference
 									s = string(b)
 								}
-								r = append(r, []rune(utf16Decode(s))...)
+								r = append(r, m.decodeRepl(text, s)...)
 								continue Parse
 							}
 							if bfrange.dst.Kind() == Array {
@@ -313,16 +428,16 @@ Parse:
 								v := bfrange.dst.Index(int(n))
 								if v.Kind() == String {
 									s := v.RawString()
-									r = append(r, []rune(utf16Decode(s))...)
+									r = append(r, m.decodeRepl(text, s)...)
 									continue Parse
 								}
 							}
 
-							r = append(r, noRune)
+							r = append(r, m.unmapped(text)...)
 							continue Parse
 						}
 					}
-					r = append(r, noRune)
+					r = append(r, m.unmapped(text)...)
 					continue Parse
 				}
 			}
@@ -338,9 +453,9 @@ func readCmap(toUnicode Value) *cmap {
 	n := -1
 	var m cmap
 	ok := true
-	Interpret(toUnicode, func(stk *Stack, op string) {
+	err := InterpretErr(toUnicode, func(stk *Stack, op string) error {
 		if !ok {
-			return
+			return nil
 		}
 		switch op {
 		case "findresource":
@@ -356,13 +471,13 @@ func readCmap(toUnicode Value) *cmap {
 		case "endcodespacerange":
 			if n < 0 {
 				ok = false
-				return
+				return nil
 			}
 			for i := 0; i < n; i++ {
 				hi, lo := stk.Pop().RawString(), stk.Pop().RawString()
 				if len(lo) == 0 || len(lo) != len(hi) {
 					ok = false
-					return
+					return nil
 				}
 				m.space[len(lo)-1] = append(m.space[len(lo)-1], byteRange{lo, hi})
 			}
@@ -371,7 +486,7 @@ func readCmap(toUnicode Value) *cmap {
 			n = int(stk.Pop().Int64())
 		case "endbfchar":
 			if n < 0 {
-				panic("missing beginbfchar")
+				return errors.New("endbfchar without beginbfchar")
 			}
 			for i := 0; i < n; i++ {
 				repl, orig := stk.Pop().RawString(), stk.Pop().RawString()
@@ -381,21 +496,44 @@ func readCmap(toUnicode Value) *cmap {
 			n = int(stk.Pop().Int64())
 		case "endbfrange":
 			if n < 0 {
-				panic("missing beginbfrange")
+				return errors.New("endbfrange without beginbfrange")
 			}
 			for i := 0; i < n; i++ {
 				dst, srcHi, srcLo := stk.Pop(), stk.Pop().RawString(), stk.Pop().RawString()
 				m.bfrange = append(m.bfrange, bfrange{srcLo, srcHi, dst})
 			}
+		case "begincidchar", "begincidrange":
+			n = int(stk.Pop().Int64())
+		case "endcidchar":
+			if n < 0 {
+				return errors.New("endcidchar without begincidchar")
+			}
+			for i := 0; i < n; i++ {
+				cid, code := int(stk.Pop().Int64()), stk.Pop().RawString()
+				m.cidrange = append(m.cidrange, cidRange{code, code, cid})
+			}
+		case "endcidrange":
+			if n < 0 {
+				return errors.New("endcidrange without begincidrange")
+			}
+			for i := 0; i < n; i++ {
+				cid, hi, lo := int(stk.Pop().Int64()), stk.Pop().RawString(), stk.Pop().RawString()
+				m.cidrange = append(m.cidrange, cidRange{lo, hi, cid})
+			}
+		case "usecmap":
+			m.usecmap = stk.Pop().Name()
 		case "defineresource":
 			stk.Pop().Name() // category
 			value := stk.Pop()
 			stk.Pop().Name() // key
 			stk.Push(value)
-		default:
-			println("interp\t", op)
 		}
+		return nil
 	})
+	if err != nil {
+		toUnicode.r.report(err)
+		return nil
+	}
 	if !ok {
 		return nil
 	}
@@ -458,40 +596,46 @@ type gstate struct {
 	Tlm   matrix
 	Trm   matrix
 	CTM   matrix
+
+	metrics *fontMetrics // of Tf
+}
+
+// badOperands returns the error for operator op called with the wrong operands.
+func badOperands(op string, args []Value) error {
+	return fmt.Errorf("bad %s operator: %d operands %v", op, len(args), args)
 }
 
 // GetPlainText returns the page's all text without format.
-// fonts can be passed in (to improve parsing performance) or left nil
+// Fonts are looked up in the page's /Resources, and in a Form XObject's
+// own /Resources while it is drawn. fonts, which may be nil, only overrides
+// that lookup: a name it holds selects its font instead of the page's, and
+// a name it lacks is still found in /Resources. It does not apply inside
+// forms that have their own /Resources.
+// If the content stream is malformed, GetPlainText returns an *Error.
 func (p Page) GetPlainText(fonts map[string]*Font) (result string, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			result = ""
-			err = errors.New(fmt.Sprint(r))
-		}
-	}()
-
-	strm := p.V.Key("Contents")
+	strm, err := p.V.KeyErr("Contents")
+	if err != nil {
+		return "", err
+	}
 	var enc TextEncoding = &nopEncoder{}
 
-	if fonts == nil {
-		fonts = make(map[string]*Font)
-		for _, font := range p.Fonts() {
-			f := p.Font(font)
-			fonts[font] = &f
-		}
+	res := newResources(p.Resources())
+	for name, f := range fonts {
+		res.fonts[name] = f
 	}
 
 	var textBuilder bytes.Buffer
 	showText := func(s string) {
 		for _, ch := range enc.Decode(s) {
-			_, err := textBuilder.WriteRune(ch)
-			if err != nil {
-				panic(err)
-			}
+			textBuilder.WriteRune(ch)
 		}
 	}
 
-	Interpret(strm, func(stk *Stack, op string) {
+	form := func(xobj Value) func() {
+		saved := enc
+		return func() { enc = saved }
+	}
+	err = interpretContent(strm, res, func(res *resources, stk *Stack, op string) error {
 		n := stk.Len()
 		args := make([]Value, n)
 		for i := n - 1; i >= 0; i-- {
@@ -500,34 +644,34 @@ func (p Page) GetPlainText(fonts map[string]*Font) (result string, err error) {
 
 		switch op {
 		default:
-			return
+			return nil
 		case "T*": // move to start of next line
 			showText("\n")
 		case "Tf": // set text font and size
 			if len(args) != 2 {
-				panic("bad TL")
-			}
-			if font, ok := fonts[args[0].Name()]; ok {
-				enc = font.Encoder()
-			} else {
-				enc = &nopEncoder{}
+				return badOperands(op, args)
 			}
+			enc = res.encoder(args[0].Name())
 		case "\"": // set spacing, move to next line, and show text
 			if len(args) != 3 {
-				panic("bad \" operator")
+				return badOperands(op, args)
 			}
+			args = args[2:]
 			fallthrough
 		case "'": // move to next line and show text
 			if len(args) != 1 {
-				panic("bad ' operator")
+				return badOperands(op, args)
 			}
 			fallthrough
 		case "Tj": // show text
 			if len(args) != 1 {
-				panic("bad Tj operator")
+				return badOperands(op, args)
 			}
 			showText(args[0].RawString())
 		case "TJ": // show text, allowing individual glyph positioning
+			if len(args) != 1 {
+				return badOperands(op, args)
+			}
 			v := args[0]
 			for i := 0; i < v.Len(); i++ {
 				x := v.Index(i)
@@ -536,7 +680,11 @@ func (p Page) GetPlainText(fonts map[string]*Font) (result string, err error) {
 				}
 			}
 		}
-	})
+		return nil
+	}, form)
+	if err != nil {
+		return "", err
+	}
 	return textBuilder.String(), nil
 }
 
@@ -552,23 +700,12 @@ type Columns []*Column
 // GetTextByColumn returns the page's all text grouped by column
 func (p Page) GetTextByColumn() (Columns, error) {
 	result := Columns{}
-	var err error
-
-	defer func() {
-		if r := recover(); r != nil {
-			result = Columns{}
-			err = errors.New(fmt.Sprint(r))
-		}
-	}()
 
 	showText := func(enc TextEncoding, currentX, currentY float64, s string) {
 		var textBuilder bytes.Buffer
 
 		for _, ch := range enc.Decode(s) {
-			_, err := textBuilder.WriteRune(ch)
-			if err != nil {
-				panic(err)
-			}
+			textBuilder.WriteRune(ch)
 		}
 		text := Text{
 			S: textBuilder.String(),
@@ -597,7 +734,9 @@ func (p Page) GetTextByColumn() (Columns, error) {
 		currentColumn.Content = append(currentColumn.Content, text)
 	}
 
-	p.walkTextBlocks(showText)
+	if err := p.walkTextBlocks(showText); err != nil {
+		return nil, err
+	}
 
 	for _, column := range result {
 		sort.Sort(column.Content)
@@ -607,7 +746,7 @@ func (p Page) GetTextByColumn() (Columns, error) {
 		return result[i].Position < result[j].Position
 	})
 
-	return result, err
+	return result, nil
 }
 
 // Row represents the contents of a row
@@ -622,22 +761,11 @@ type Rows []*Row
 // GetTextByRow returns the page's all text grouped by rows
 func (p Page) GetTextByRow() (Rows, error) {
 	result := Rows{}
-	var err error
-
-	defer func() {
-		if r := recover(); r != nil {
-			result = Rows{}
-			err = errors.New(fmt.Sprint(r))
-		}
-	}()
 
 	showText := func(enc TextEncoding, currentX, currentY float64, s string) {
 		var textBuilder bytes.Buffer
 		for _, ch := range enc.Decode(s) {
-			_, err := textBuilder.WriteRune(ch)
-			if err != nil {
-				panic(err)
-			}
+			textBuilder.WriteRune(ch)
 		}
 
 		// if DebugOn {
@@ -671,7 +799,9 @@ func (p Page) GetTextByRow() (Rows, error) {
 		currentRow.Content = append(currentRow.Content, text)
 	}
 
-	p.walkTextBlocks(showText)
+	if err := p.walkTextBlocks(showText); err != nil {
+		return nil, err
+	}
 
 	for _, row := range result {
 		sort.Sort(row.Content)
@@ -681,21 +811,22 @@ func (p Page) GetTextByRow() (Rows, error) {
 		return result[i].Position > result[j].Position
 	})
 
-	return result, err
+	return result, nil
 }
 
-func (p Page) walkTextBlocks(walker func(enc TextEncoding, x, y float64, s string)) {
-	strm := p.V.Key("Contents")
-
-	fonts := make(map[string]*Font)
-	for _, font := range p.Fonts() {
-		f := p.Font(font)
-		fonts[font] = &f
+func (p Page) walkTextBlocks(walker func(enc TextEncoding, x, y float64, s string)) error {
+	strm, err := p.V.KeyErr("Contents")
+	if err != nil {
+		return err
 	}
 
 	var enc TextEncoding = &nopEncoder{}
 	var currentX, currentY float64
-	Interpret(strm, func(stk *Stack, op string) {
+	form := func(xobj Value) func() {
+		saved := enc
+		return func() { enc = saved }
+	}
+	return interpretContent(strm, newResources(p.Resources()), func(res *resources, stk *Stack, op string) error {
 		n := stk.Len()
 		args := make([]Value, n)
 		for i := n - 1; i >= 0; i-- {
@@ -708,35 +839,34 @@ func (p Page) walkTextBlocks(walker func(enc TextEncoding, x, y float64, s strin
 
 		switch op {
 		default:
-			return
+			return nil
 		case "T*": // move to start of next line
 		case "Tf": // set text font and size
 			if len(args) != 2 {
-				panic("bad TL")
-			}
-
-			if font, ok := fonts[args[0].Name()]; ok {
-				enc = font.Encoder()
-			} else {
-				enc = &nopEncoder{}
+				return badOperands(op, args)
 			}
+			enc = res.encoder(args[0].Name())
 		case "\"": // set spacing, move to next line, and show text
 			if len(args) != 3 {
-				panic("bad \" operator")
+				return badOperands(op, args)
 			}
+			args = args[2:]
 			fallthrough
 		case "'": // move to next line and show text
 			if len(args) != 1 {
-				panic("bad ' operator")
+				return badOperands(op, args)
 			}
 			fallthrough
 		case "Tj": // show text
 			if len(args) != 1 {
-				panic("bad Tj operator")
+				return badOperands(op, args)
 			}
 
 			walker(enc, currentX, currentY, args[0].RawString())
 		case "TJ": // show text, allowing individual glyph positioning
+			if len(args) != 1 {
+				return badOperands(op, args)
+			}
 			v := args[0]
 			for i := 0; i < v.Len(); i++ {
 				x := v.Index(i)
@@ -747,15 +877,31 @@ func (p Page) walkTextBlocks(walker func(enc TextEncoding, x, y float64, s strin
 		case "Td":
 			walker(enc, currentX, currentY, "")
 		case "Tm":
+			if len(args) != 6 {
+				return badOperands(op, args)
+			}
 			currentX = args[4].Float64()
 			currentY = args[5].Float64()
 		}
-	})
+		return nil
+	}, form)
 }
 
 // Content returns the page's content.
+// Errors in the content stream are reported to the Reader's error handler.
 func (p Page) Content() Content {
-	strm := p.V.Key("Contents")
+	c, err := p.ContentErr()
+	p.V.r.report(err)
+	return c
+}
+
+// ContentErr is like Content but returns the first error in the content stream,
+// along with the content found before it.
+func (p Page) ContentErr() (Content, error) {
+	strm, err := p.V.KeyErr("Contents")
+	if err != nil {
+		return Content{}, err
+	}
 	var enc TextEncoding = &nopEncoder{}
 
 	var g = gstate{
@@ -765,24 +911,37 @@ func (p Page) Content() Content {
 
 	var text []Text
 	showText := func(s string) {
-		n := 0
-		decoded := enc.Decode(s)
-		for _, ch := range decoded {
-			var w0 float64
-			if n < len(s) {
-				w0 = g.Tf.Width(int(s[n]))
+		fm := g.metrics
+		if fm == nil {
+			fm = newFontMetrics(g.Tf)
+		}
+		f := g.Tf.BaseFont()
+		if i := strings.Index(f, "+"); i >= 0 {
+			f = f[i+1:]
+		}
+		for len(s) > 0 {
+			c := fm.split(s)
+			s = s[len(c.code):]
+			w0 := fm.width(c)
+			spacing := g.Tc
+			if len(c.code) == 1 && c.code[0] == ' ' {
+				spacing += g.Tw
 			}
-			n++
 
-			f := g.Tf.BaseFont()
-			if i := strings.Index(f, "+"); i >= 0 {
-				f = f[i+1:]
+			if fm.vertical {
+				// The glyph's vertical origin is at the text position.
+				w1y, vx, vy := fm.cid.vertical(c.cid)
+				Trm := matrix{{g.Tfs * g.Th, 0, 0}, {0, g.Tfs, 0}, {-vx / 1000 * g.Tfs * g.Th, -vy/1000*g.Tfs + g.Trise, 1}}.mul(g.Tm).mul(g.CTM)
+				text = append(text, Text{f, Trm[0][0], Trm[2][0], Trm[2][1], w0 / 1000 * Trm[0][0], enc.Decode(c.code)})
+				ty := w1y/1000*g.Tfs + spacing
+				g.Tm = matrix{{1, 0, 0}, {0, 1, 0}, {0, ty, 1}}.mul(g.Tm)
+				continue
 			}
 
 			Trm := matrix{{g.Tfs * g.Th, 0, 0}, {0, g.Tfs, 0}, {0, g.Trise, 1}}.mul(g.Tm).mul(g.CTM)
-			text = append(text, Text{f, Trm[0][0], Trm[2][0], Trm[2][1], w0 / 1000 * Trm[0][0], string(ch)})
+			text = append(text, Text{f, Trm[0][0], Trm[2][0], Trm[2][1], w0 / 1000 * Trm[0][0], enc.Decode(c.code)})
 
-			tx := w0/1000*g.Tfs + g.Tc
+			tx := w0/1000*g.Tfs + spacing
 			tx *= g.Th
 			g.Tm = matrix{{1, 0, 0}, {0, 1, 0}, {tx, 0, 1}}.mul(g.Tm)
 		}
@@ -790,7 +949,13 @@ func (p Page) Content() Content {
 
 	var rect []Rect
 	var gstack []gstate
-	Interpret(strm, func(stk *Stack, op string) {
+	form := func(xobj Value) func() {
+		saved, savedEnc, savedStack := g, enc, gstack
+		g.CTM = formMatrix(xobj).mul(g.CTM)
+		gstack = nil
+		return func() { g, enc, gstack = saved, savedEnc, savedStack }
+	}
+	err = interpretContent(strm, newResources(p.Resources()), func(res *resources, stk *Stack, op string) error {
 		n := stk.Len()
 		args := make([]Value, n)
 		for i := n - 1; i >= 0; i-- {
@@ -801,11 +966,11 @@ func (p Page) Content() Content {
 			// if DebugOn {
 			// 	fmt.Println(op, args)
 			// }
-			return
+			return nil
 
 		case "cm": // update g.CTM
 			if len(args) != 6 {
-				panic("bad g.Tm")
+				return badOperands(op, args)
 			}
 			var m matrix
 			for i := 0; i < 6; i++ {
@@ -833,7 +998,7 @@ func (p Page) Content() Content {
 
 		case "re": // append rectangle to path
 			if len(args) != 4 {
-				panic("bad re")
+				return badOperands(op, args)
 			}
 			x, y, w, h := args[0].Float64(), args[1].Float64(), args[2].Float64(), args[3].Float64()
 			rect = append(rect, Rect{Point{x, y}, Point{x + w, y + h}})
@@ -842,6 +1007,9 @@ func (p Page) Content() Content {
 			gstack = append(gstack, g)
 
 		case "Q": // restore graphics state
+			if len(gstack) == 0 {
+				return errors.New("Q without matching q")
+			}
 			n := len(gstack) - 1
 			g = gstack[n]
 			gstack = gstack[:n]
@@ -859,19 +1027,19 @@ func (p Page) Content() Content {
 
 		case "Tc": // set character spacing
 			if len(args) != 1 {
-				panic("bad g.Tc")
+				return badOperands(op, args)
 			}
 			g.Tc = args[0].Float64()
 
 		case "TD": // move text position and set leading
 			if len(args) != 2 {
-				panic("bad Td")
+				return badOperands(op, args)
 			}
 			g.Tl = -args[1].Float64()
 			fallthrough
 		case "Td": // move text position
 			if len(args) != 2 {
-				panic("bad Td")
+				return badOperands(op, args)
 			}
 			tx := args[0].Float64()
 			ty := args[1].Float64()
@@ -881,19 +1049,17 @@ func (p Page) Content() Content {
 
 		case "Tf": // set text font and size
 			if len(args) != 2 {
-				panic("bad TL")
+				return badOperands(op, args)
 			}
 			f := args[0].Name()
-			g.Tf = p.Font(f)
-			enc = g.Tf.Encoder()
-			if enc == nil {
-				enc = &nopEncoder{}
-			}
+			g.Tf = *res.font(f)
+			enc = res.encoder(f)
 			g.Tfs = args[1].Float64()
+			g.metrics = res.fontMetrics(f)
 
 		case "\"": // set spacing, move to next line, and show text
 			if len(args) != 3 {
-				panic("bad \" operator")
+				return badOperands(op, args)
 			}
 			g.Tw = args[0].Float64()
 			g.Tc = args[1].Float64()
@@ -901,7 +1067,7 @@ func (p Page) Content() Content {
 			fallthrough
 		case "'": // move to next line and show text
 			if len(args) != 1 {
-				panic("bad ' operator")
+				return badOperands(op, args)
 			}
 			x := matrix{{1, 0, 0}, {0, 1, 0}, {0, -g.Tl, 1}}
 			g.Tlm = x.mul(g.Tlm)
@@ -909,11 +1075,14 @@ func (p Page) Content() Content {
 			fallthrough
 		case "Tj": // show text
 			if len(args) != 1 {
-				panic("bad Tj operator")
+				return badOperands(op, args)
 			}
 			showText(args[0].RawString())
 
 		case "TJ": // show text, allowing individual glyph positioning
+			if len(args) != 1 {
+				return badOperands(op, args)
+			}
 			v := args[0]
 			for i := 0; i < v.Len(); i++ {
 				x := v.Index(i)
@@ -925,6 +1094,9 @@ func (p Page) Content() Content {
 					} else {
 						showText(x.RawString())
 					}
+				} else if g.metrics != nil && g.metrics.vertical {
+					ty := -x.Float64() / 1000 * g.Tfs
+					g.Tm = matrix{{1, 0, 0}, {0, 1, 0}, {0, ty, 1}}.mul(g.Tm)
 				} else {
 					tx := -x.Float64() / 1000 * g.Tfs * g.Th
 					g.Tm = matrix{{1, 0, 0}, {0, 1, 0}, {tx, 0, 1}}.mul(g.Tm)
@@ -934,13 +1106,13 @@ func (p Page) Content() Content {
 
 		case "TL": // set text leading
 			if len(args) != 1 {
-				panic("bad TL")
+				return badOperands(op, args)
 			}
 			g.Tl = args[0].Float64()
 
 		case "Tm": // set text matrix and line matrix
 			if len(args) != 6 {
-				panic("bad g.Tm")
+				return badOperands(op, args)
 			}
 			var m matrix
 			for i := 0; i < 6; i++ {
@@ -952,30 +1124,31 @@ func (p Page) Content() Content {
 
 		case "Tr": // set text rendering mode
 			if len(args) != 1 {
-				panic("bad Tr")
+				return badOperands(op, args)
 			}
 			g.Tmode = int(args[0].Int64())
 
 		case "Ts": // set text rise
 			if len(args) != 1 {
-				panic("bad Ts")
+				return badOperands(op, args)
 			}
 			g.Trise = args[0].Float64()
 
 		case "Tw": // set word spacing
 			if len(args) != 1 {
-				panic("bad g.Tw")
+				return badOperands(op, args)
 			}
 			g.Tw = args[0].Float64()
 
 		case "Tz": // set horizontal text scaling
 			if len(args) != 1 {
-				panic("bad Tz")
+				return badOperands(op, args)
 			}
 			g.Th = args[0].Float64() / 100
 		}
-	})
-	return Content{text, rect}
+		return nil
+	}, form)
+	return Content{text, rect}, err
 }
 
 // TextVertical implements sort.Interface for sorting
@@ -5,6 +5,7 @@
 package pdf
 
 import (
+	"errors"
 	"fmt"
 	"io"
 )
@@ -43,6 +44,9 @@ func newDict() Value {
 // to implement op.
 //
 // Interpret handles the operators "dict", "currentdict", "begin", "end", "def", and "pop" itself.
+// In content streams, it skips the data of inline images: the do function
+// sees BI, then ID with the image dictionary's keys and values on the stack,
+// then EI.
 //
 // Interpret is not a full-blown PostScript interpreter. Its job is to handle the
 // very limited PostScript found in certain supporting file formats embedded
@@ -51,8 +55,26 @@ func newDict() Value {
 //
 // There is no support for executable blocks, among other limitations.
 //
+// Interpret stops at the first error and reports it to the stream's Reader.
+// Use InterpretErr to receive the error instead.
+//
 func Interpret(strm Value, do func(stk *Stack, op string)) {
-	rd := strm.Reader()
+	err := InterpretErr(strm, func(stk *Stack, op string) error {
+		do(stk, op)
+		return nil
+	})
+	strm.r.report(err)
+}
+
+// InterpretErr is like Interpret, but the do function may return an error
+// to stop the interpretation. InterpretErr returns an *Error giving the
+// stream's object number and the offset in the decoded stream data just
+// before the operator that failed.
+func InterpretErr(strm Value, do func(stk *Stack, op string) error) error {
+	rd, err := strm.ReaderErr()
+	if err != nil {
+		return err
+	}
 	b := newBuffer(rd, 0)
 	b.allowEOF = true
 	b.allowObjptr = false
@@ -60,8 +82,13 @@ func Interpret(strm Value, do func(stk *Stack, op string)) {
 	var stk Stack
 	var dicts []dict
 
+	fail := func(offset int64, err error) error {
+		return &Error{Obj: strm.ptr.id, Gen: strm.ptr.gen, Offset: offset, InStream: true, Err: err}
+	}
+
 Reading:
 	for {
+		offset := b.readOffset()
 		tok := b.readToken()
 		if tok == io.EOF {
 			break
@@ -70,6 +97,16 @@ Reading:
 			switch kw {
 			case "null", "[", "]", "<<", ">>":
 				break
+			case "ID":
+				n := inlineImageLength(&stk)
+				if err := do(&stk, "ID"); err != nil {
+					return fail(offset, err)
+				}
+				b.skipInlineImage(n)
+				if err := do(&stk, "EI"); err != nil {
+					return fail(b.readOffset(), err)
+				}
+				continue
 			default:
 				for i := len(dicts) - 1; i >= 0; i-- {
 					if v, ok := dicts[i][name(kw)]; ok {
@@ -77,7 +114,9 @@ Reading:
 						continue Reading
 					}
 				}
-				do(&stk, string(kw))
+				if err := do(&stk, string(kw)); err != nil {
+					return fail(offset, err)
+				}
 				continue
 			case "dict":
 				stk.Pop()
@@ -85,31 +124,31 @@ Reading:
 				continue
 			case "currentdict":
 				if len(dicts) == 0 {
-					panic("no current dictionary")
+					return fail(offset, errors.New("no current dictionary"))
 				}
 				stk.Push(Value{nil, objptr{}, dicts[len(dicts)-1]})
 				continue
 			case "begin":
 				d := stk.Pop()
 				if d.Kind() != Dict {
-					panic("cannot begin non-dict")
+					return fail(offset, errors.New("cannot begin non-dict"))
 				}
 				dicts = append(dicts, d.data.(dict))
 				continue
 			case "end":
 				if len(dicts) <= 0 {
-					panic("mismatched begin/end")
+					return fail(offset, errors.New("mismatched begin/end"))
 				}
 				dicts = dicts[:len(dicts)-1]
 				continue
 			case "def":
 				if len(dicts) <= 0 {
-					panic("def without open dict")
+					return fail(offset, errors.New("def without open dict"))
 				}
 				val := stk.Pop()
 				key, ok := stk.Pop().data.(name)
 				if !ok {
-					panic("def of non-name")
+					return fail(offset, errors.New("def of non-name"))
 				}
 				dicts[len(dicts)-1][key] = val.data
 				continue
@@ -121,10 +160,29 @@ Reading:
 		b.unreadToken(tok)
 		obj, err := b.readObject()
 		if err != nil {
-			return
+			return fail(offset, err)
 		}
 		stk.Push(Value{nil, objptr{}, obj})
 	}
+	if b.err != nil {
+		e := b.err.(*Error)
+		strm.r.report(&Error{Obj: strm.ptr.id, Gen: strm.ptr.gen, Offset: e.Offset, InStream: true, Err: e.Err})
+	}
+	return nil
+}
+
+// inlineImageLength returns the length of the inline image data
+// given by the /L or /Length entry of the image dictionary on stk,
+// or -1 if there is none.
+func inlineImageLength(stk *Stack) int64 {
+	for i := 0; i+1 < len(stk.stack); i += 2 {
+		if k := stk.stack[i].Name(); k == "L" || k == "Length" {
+			if v := stk.stack[i+1]; v.Kind() == Integer && v.Int64() >= 0 {
+				return v.Int64()
+			}
+		}
+	}
+	return -1
 }
 
 type seqReader struct {
@@ -33,7 +33,9 @@
 // Returning zero values this way, especially from the Dict and Array accessors,
 // which themselves return Values, makes it possible to traverse a PDF quickly
 // without writing any error checking. On the other hand, it means that mistakes
-// can go unreported.
+// can go unreported. To see them, set an error handler with Reader.SetErrorHandler,
+// check Reader.Err after a traversal, or use the KeyErr, IndexErr, and ReaderErr
+// accessors, which return an *Error giving the object number and byte offset.
 //
 // The basic structure of the PDF file is exposed as the graph of Values.
 //
@@ -49,31 +51,18 @@ package pdf
 // BUG(rsc): The package is incomplete, although it has been used successfully on some
 // large real-world PDF files.
 
-// BUG(rsc): There is no support for closing open PDF files. If you drop all references to a Reader,
-// the underlying reader will eventually be garbage collected.
-
-// BUG(rsc): The library makes no attempt at efficiency. A value cache maintained in the Reader
-// would probably help significantly.
-
-// BUG(rsc): The support for reading encrypted files is weak.
-
-// BUG(rsc): The Value API does not support error reporting. The intent is to allow users to
-// set an error reporting callback in Reader, but that code has not been implemented.
+// BUG(rsc): Only the standard security handler is supported for encrypted files;
+// public-key security handlers are not.
 
 import (
 	"bytes"
-	"compress/zlib"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/md5"
-	"crypto/rc4"
-	"encoding/ascii85"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"sort"
 	"strconv"
+	"sync"
 )
 
 // A Reader is a single PDF file open for reading.
@@ -83,8 +72,21 @@ type Reader struct {
 	xref       []xref
 	trailer    dict
 	trailerptr objptr
-	key        []byte
-	useAES     bool
+	base       int64  // offset of the %PDF- header; xref offsets count from here
+	version    string // version in the header, see Version
+	crypt      *crypt // nil if the file is not encrypted
+	gaiji      *GaijiMap
+	cidTables  map[string]*CIDTable // by Ordering, see SetCIDTable
+	repaired   error     // why the xref table was rebuilt, see Repaired
+	closer     io.Closer // set when the Reader owns f
+	closed     bool
+	errh       func(error)
+	err        error
+	cache      *objCache // parsed objects
+	objstm     *objCache // decoded object streams
+	metrics    *objCache // parsed CIDFont widths, see readCIDMetrics
+	pages      []Value   // flattened page tree, see pageIndex
+	pagesOnce  sync.Once
 }
 
 type xref struct {
@@ -94,16 +96,11 @@ type xref struct {
 	offset   int64
 }
 
-func (r *Reader) errorf(format string, args ...interface{}) {
-	panic(fmt.Errorf(format, args...))
-}
-
 // Open opens a file for reading.
+// The Reader owns the file; call Close to release it.
 func Open(file string) (*Reader, error) {
-	// TODO: Deal with closing file.
 	f, err := os.Open(file)
 	if err != nil {
-		f.Close()
 		return nil, err
 	}
 	fi, err := f.Stat()
@@ -111,81 +108,218 @@ func Open(file string) (*Reader, error) {
 		f.Close()
 		return nil, err
 	}
-	return NewReader(f, fi.Size())
+	r, err := NewReader(f, fi.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
 }
 
 // NewReader opens a file for reading, using the data in f with the given total size.
+// The caller keeps ownership of f: Close does not close it.
 func NewReader(f io.ReaderAt, size int64) (*Reader, error) {
 	return NewReaderEncrypted(f, size, nil)
 }
 
+// NewReaderFromBytes opens a file for reading, using the PDF data in b.
+// The caller must not modify b while the Reader is in use.
+func NewReaderFromBytes(b []byte) (*Reader, error) {
+	return NewReader(bytes.NewReader(b), int64(len(b)))
+}
+
+// Close releases the Reader's caches and, if the Reader was returned by Open,
+// closes the underlying file. Using the Reader or its Values after Close
+// reports ErrClosed.
+func (r *Reader) Close() error {
+	if r.closed {
+		return &Error{Offset: -1, Err: ErrClosed}
+	}
+	r.closed = true
+	r.cache.reset()
+	r.objstm.reset()
+	r.metrics.reset()
+	r.pages = nil
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
 // NewReaderEncrypted opens a file for reading, using the data in f with the given total size.
 // If the PDF is encrypted, NewReaderEncrypted calls pw repeatedly to obtain passwords
 // to try. If pw returns the empty string, NewReaderEncrypted stops trying to decrypt
 // the file and returns an error.
 func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, error) {
-	buf := make([]byte, 10)
-	f.ReadAt(buf, 0)
-	if !bytes.HasPrefix(buf, []byte("%PDF-1.")) || buf[7] < '0' || buf[7] > '7' || buf[8] != '\r' && buf[8] != '\n' {
-		return nil, fmt.Errorf("not a PDF file: invalid header")
+	base, version, err := findHeader(f, size)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reader{
+		f:       f,
+		end:     size,
+		base:    base,
+		version: version,
+		cache:   newObjCache(objCacheSize),
+		objstm:  newObjCache(objStmCacheSize),
+		metrics: newObjCache(metricsCacheSize),
+	}
+	err = r.readXrefAt()
+	if err != nil && base > 0 {
+		// The junk before the header may have been there when the
+		// file was written, in which case offsets count from byte 0.
+		r.base = 0
+		r.cache.reset()
+		r.err = nil
+		if r.readXrefAt() == nil {
+			err = nil
+		} else {
+			r.base = base
+		}
+	}
+	if err != nil {
+		if rerr := r.rebuildXref(); rerr != nil {
+			return nil, err
+		}
+		r.repaired = err
+	}
+	if r.trailer["Encrypt"] != nil {
+		if err := r.authenticate(pw); err != nil {
+			return nil, err
+		}
+	}
+	if r.repaired != nil {
+		if err := r.finishRepair(); err != nil {
+			return nil, r.repaired
+		}
+	}
+	return r, nil
+}
+
+// authenticate tries the empty password and then each password from pw.
+func (r *Reader) authenticate(pw func() string) error {
+	err := r.initEncrypt("")
+	if err == nil {
+		return nil
+	}
+	if pw == nil || err != ErrInvalidPassword {
+		return err
+	}
+	for {
+		next := pw()
+		if next == "" {
+			break
+		}
+		if r.initEncrypt(next) == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// headerSearch is how far into the file findHeader looks for %PDF-.
+// Mail gateways and some scanners put a few lines or a byte order mark
+// before the header.
+const headerSearch = 1024
+
+// findHeader locates the %PDF-M.m header within the first headerSearch
+// bytes of f and returns its offset and the version it declares.
+func findHeader(f io.ReaderAt, size int64) (int64, string, error) {
+	n := int64(headerSearch)
+	if size < n {
+		n = size
+	}
+	buf := make([]byte, n)
+	n64, _ := f.ReadAt(buf, 0)
+	buf = buf[:n64]
+	i := bytes.Index(buf, []byte("%PDF-"))
+	if i < 0 {
+		return 0, "", fmt.Errorf("not a PDF file: invalid header")
 	}
-	end := size
+	v := buf[i+5:]
+	if len(v) < 3 || v[1] != '.' || len(v) > 3 && '0' <= v[3] && v[3] <= '9' {
+		return 0, "", fmt.Errorf("not a PDF file: invalid header")
+	}
+	version := string(v[:3])
+	if version < "1.0" || version > "1.7" && version != "2.0" {
+		return 0, "", fmt.Errorf("unsupported PDF version %q", version)
+	}
+	return int64(i), version, nil
+}
+
+// Version returns the PDF version the file declares: the version in the
+// %PDF- header, or the catalog's /Version entry if that is later
+// (PDF 32000-1:2008, §7.2.2).
+func (r *Reader) Version() string {
+	v := r.version
+	if cv := r.Trailer().Key("Root").Key("Version").Name(); len(cv) == 3 && cv[1] == '.' && cv > v {
+		v = cv
+	}
+	return v
+}
+
+// readXrefAt reads the cross-reference table named by the final startxref
+// and checks that it leads to the document catalog.
+func (r *Reader) readXrefAt() error {
 	const endChunk = 100
-	buf = make([]byte, endChunk)
-	f.ReadAt(buf, end-endChunk)
-	for len(buf) > 0 && buf[len(buf)-1] == '\n' || buf[len(buf)-1] == '\r' {
-		buf = buf[:len(buf)-1]
+	pos := r.end - endChunk
+	if pos < 0 {
+		pos = 0
 	}
-	buf = bytes.TrimRight(buf, "\r\n\t ")
+	buf := make([]byte, r.end-pos)
+	r.f.ReadAt(buf, pos)
+	buf = bytes.TrimRight(buf, "\r\n\t\x00 ")
 	if !bytes.HasSuffix(buf, []byte("%%EOF")) {
-		return nil, fmt.Errorf("not a PDF file: missing %%%%EOF")
+		return fmt.Errorf("not a PDF file: missing %%%%EOF")
 	}
 	i := findLastLine(buf, "startxref")
 	if i < 0 {
-		return nil, fmt.Errorf("malformed PDF file: missing final startxref")
+		return fmt.Errorf("malformed PDF file: missing final startxref")
 	}
 
-	r := &Reader{
-		f:   f,
-		end: end,
-	}
-	pos := end - endChunk + int64(i)
-	b := newBuffer(io.NewSectionReader(f, pos, end-pos), pos)
+	pos += int64(i)
+	b := newBuffer(io.NewSectionReader(r.f, pos, r.end-pos), pos)
 	if b.readToken() != keyword("startxref") {
-		return nil, fmt.Errorf("malformed PDF file: missing startxref")
+		return fmt.Errorf("malformed PDF file: missing startxref")
 	}
 	startxref, ok := b.readToken().(int64)
 	if !ok {
-		return nil, fmt.Errorf("malformed PDF file: startxref not followed by integer")
+		return fmt.Errorf("malformed PDF file: startxref not followed by integer")
+	}
+	startxref += r.base
+	if startxref <= 0 || startxref >= r.end {
+		return fmt.Errorf("malformed PDF file: startxref %d outside file", startxref-r.base)
 	}
 	b = newBuffer(io.NewSectionReader(r.f, startxref, r.end-startxref), startxref)
 	xref, trailerptr, trailer, err := readXref(r, b)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	r.xref = xref
 	r.trailer = trailer
 	r.trailerptr = trailerptr
-	if trailer["Encrypt"] == nil {
-		return r, nil
+	return r.checkCatalog()
+}
+
+// checkCatalog reports whether the trailer leads to a document catalog
+// with a page tree, as a quick test that the xref offsets are right.
+func (r *Reader) checkCatalog() error {
+	root, err := r.Trailer().KeyErr("Root")
+	if err != nil {
+		return err
 	}
-	err = r.initEncrypt("")
-	if err == nil {
-		return r, nil
+	if root.Kind() != Dict {
+		return fmt.Errorf("malformed PDF: trailer Root is not a dictionary")
 	}
-	if pw == nil || err != ErrInvalidPassword {
-		return nil, err
+	pages, err := root.KeyErr("Pages")
+	if err != nil {
+		return err
 	}
-	for {
-		next := pw()
-		if next == "" {
-			break
-		}
-		if r.initEncrypt(next) == nil {
-			return r, nil
-		}
+	if pages.Kind() != Dict {
+		return fmt.Errorf("malformed PDF: catalog Pages is not a dictionary")
 	}
-	return nil, err
+	return nil
 }
 
 // Trailer returns the file's Trailer value.
@@ -242,6 +376,7 @@ func readXrefStream(r *Reader, b *buffer) ([]xref, objptr, dict, error) {
 		if !ok {
 			return nil, objptr{}, nil, fmt.Errorf("malformed PDF: xref Prev is not integer: %v", prevoff)
 		}
+		off += r.base
 		b := newBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
 		obj1, err := b.readObject()
 		if err != nil {
@@ -307,6 +442,7 @@ func readXrefStreamData(r *Reader, strm stream, table []xref, size int64) ([]xre
 	}
 	buf := make([]byte, wtotal)
 	data := v.Reader()
+	pos := -int64(len(buf)) // offset of buf in the decoded stream
 	for len(index) > 0 {
 		start, ok1 := index[0].(int64)
 		n, ok2 := index[1].(int64)
@@ -315,6 +451,7 @@ func readXrefStreamData(r *Reader, strm stream, table []xref, size int64) ([]xre
 		}
 		index = index[2:]
 		for i := 0; i < int(n); i++ {
+			pos += int64(len(buf))
 			_, err := io.ReadFull(data, buf)
 			if err != nil {
 				return nil, fmt.Errorf("error reading xref stream: %v", err)
@@ -336,11 +473,13 @@ func readXrefStreamData(r *Reader, strm stream, table []xref, size int64) ([]xre
 			case 0:
 				table[x] = xref{ptr: objptr{0, 65535}}
 			case 1:
-				table[x] = xref{ptr: objptr{uint32(x), uint16(v3)}, offset: int64(v2)}
+				table[x] = xref{ptr: objptr{uint32(x), uint16(v3)}, offset: r.base + int64(v2)}
 			case 2:
 				table[x] = xref{ptr: objptr{uint32(x), 0}, inStream: true, stream: objptr{uint32(v2), 0}, offset: int64(v3)}
 			default:
-				fmt.Printf("invalid xref stream type %d: %x\n", v1, buf)
+				// Entries of unknown type refer to the null object.
+				r.report(&Error{Obj: strm.ptr.id, Gen: strm.ptr.gen, Offset: pos, InStream: true,
+					Err: fmt.Errorf("invalid xref stream type %d for object %d: %x", v1, x, buf)})
 			}
 		}
 	}
@@ -359,7 +498,7 @@ func decodeInt(b []byte) int {
 func readXrefTable(r *Reader, b *buffer) ([]xref, objptr, dict, error) {
 	var table []xref
 
-	table, err := readXrefTableData(b, table)
+	table, err := readXrefTableData(b, table, r.base)
 	if err != nil {
 		return nil, objptr{}, nil, fmt.Errorf("malformed PDF: %v", err)
 	}
@@ -379,12 +518,13 @@ func readXrefTable(r *Reader, b *buffer) ([]xref, objptr, dict, error) {
 		if !ok {
 			return nil, objptr{}, nil, fmt.Errorf("malformed PDF: xref Prev is not integer: %v", prevoff)
 		}
+		off += r.base
 		b := newBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
 		tok := b.readToken()
 		if tok != keyword("xref") {
 			return nil, objptr{}, nil, fmt.Errorf("malformed PDF: xref Prev does not point to xref")
 		}
-		table, err = readXrefTableData(b, table)
+		table, err = readXrefTableData(b, table, r.base)
 		if err != nil {
 			return nil, objptr{}, nil, fmt.Errorf("malformed PDF: %v", err)
 		}
@@ -412,7 +552,9 @@ func readXrefTable(r *Reader, b *buffer) ([]xref, objptr, dict, error) {
 	return table, objptr{}, trailer, nil
 }
 
-func readXrefTableData(b *buffer, table []xref) ([]xref, error) {
+// readXrefTableData reads the sections of an xref table,
+// adding base to each offset.
+func readXrefTableData(b *buffer, table []xref, base int64) ([]xref, error) {
 	for {
 		tok := b.readToken()
 		if tok == keyword("trailer") {
@@ -438,7 +580,7 @@ func readXrefTableData(b *buffer, table []xref) ([]xref, error) {
 				table = table[:x+1]
 			}
 			if alloc == "n" && table[x].offset == 0 {
-				table[x] = xref{ptr: objptr{uint32(x), uint16(gen)}, offset: int64(off)}
+				table[x] = xref{ptr: objptr{uint32(x), uint16(gen)}, offset: base + int64(off)}
 			}
 		}
 	}
@@ -675,15 +817,23 @@ func (v Value) Name() string {
 // If v is a stream, Key applies to the stream's header dictionary.
 // If v.Kind() != Dict and v.Kind() != Stream, Key returns a null Value.
 func (v Value) Key(key string) Value {
+	x, err := v.KeyErr(key)
+	v.r.report(err)
+	return x
+}
+
+// KeyErr is like Key but returns an error if the value
+// cannot be loaded from the file.
+func (v Value) KeyErr(key string) (Value, error) {
 	x, ok := v.data.(dict)
 	if !ok {
 		strm, ok := v.data.(stream)
 		if !ok {
-			return Value{}
+			return Value{}, nil
 		}
 		x = strm.hdr
 	}
-	return v.r.resolve(v.ptr, x[name(key)])
+	return v.r.resolveErr(v.ptr, x[name(key)])
 }
 
 // Keys returns a sorted list of the keys in the dictionary v.
@@ -710,11 +860,19 @@ func (v Value) Keys() []string {
 // If v.Kind() != Array or if i is outside the array bounds,
 // Index returns a null Value.
 func (v Value) Index(i int) Value {
+	x, err := v.IndexErr(i)
+	v.r.report(err)
+	return x
+}
+
+// IndexErr is like Index but returns an error if the element
+// cannot be loaded from the file.
+func (v Value) IndexErr(i int) (Value, error) {
 	x, ok := v.data.(array)
 	if !ok || i < 0 || i >= len(x) {
-		return Value{}
+		return Value{}, nil
 	}
-	return v.r.resolve(v.ptr, x[i])
+	return v.r.resolveErr(v.ptr, x[i])
 }
 
 // Len returns the length of the array v.
@@ -728,80 +886,90 @@ func (v Value) Len() int {
 }
 
 func (r *Reader) resolve(parent objptr, x interface{}) Value {
+	v, err := r.resolveErr(parent, x)
+	r.report(err)
+	return v
+}
+
+func (r *Reader) resolveErr(parent objptr, x interface{}) (Value, error) {
 	if ptr, ok := x.(objptr); ok {
-		if ptr.id >= uint32(len(r.xref)) {
-			return Value{}
+		if r == nil || ptr.id >= uint32(len(r.xref)) {
+			return Value{}, nil
+		}
+		if r.closed {
+			return Value{}, &Error{Obj: ptr.id, Gen: ptr.gen, Offset: -1, Err: ErrClosed}
 		}
 		xref := r.xref[ptr.id]
 		if xref.ptr != ptr || !xref.inStream && xref.offset == 0 {
-			return Value{}
+			return Value{}, nil
 		}
-		// var obj object
-		if xref.inStream {
-			strm := r.resolve(parent, xref.stream)
-		Search:
-			for {
-				if strm.Kind() != Stream {
-					panic("not a stream")
-				}
-				if strm.Key("Type").Name() != "ObjStm" {
-					panic("not an object stream")
-				}
-				n := int(strm.Key("N").Int64())
-				first := strm.Key("First").Int64()
-				if first == 0 {
-					panic("missing First")
-				}
-				b := newBuffer(strm.Reader(), 0)
-				b.allowEOF = true
-				for i := 0; i < n; i++ {
-					id, _ := b.readToken().(int64)
-					off, _ := b.readToken().(int64)
-					if uint32(id) == ptr.id {
-						b.seekForward(first + off)
-						_, err := b.readObject()
-						if err != nil {
-							return Value{}
-						}
-						break Search
-					}
-				}
-				ext := strm.Key("Extends")
-				if ext.Kind() != Stream {
-					panic("cannot find object in stream")
-				}
-				strm = ext
+		if obj, ok := r.cache.get(ptr); ok {
+			x = obj
+		} else if xref.inStream {
+			obj, err := r.readInStream(parent, ptr, xref.stream)
+			if err != nil {
+				return Value{}, err
 			}
+			x = obj
 		} else {
 			b := newBuffer(io.NewSectionReader(r.f, xref.offset, r.end-xref.offset), xref.offset)
-			b.key = r.key
-			b.useAES = r.useAES
+			if ptr != r.crypt.ptr() {
+				b.crypt = r.crypt
+			}
 			obj, err := b.readObject()
 			if err != nil {
-				return Value{}
+				return Value{}, wrapError(ptr, xref.offset, err)
 			}
+			r.report(b.err)
 			def, ok := obj.(objdef)
 			if !ok {
-				panic(fmt.Errorf("loading %v: found %T instead of objdef", ptr, obj))
-				//return Value{}
+				return Value{}, newError(ptr, xref.offset, "found %T instead of objdef", obj)
 			}
 			if def.ptr != ptr {
-				panic(fmt.Errorf("loading %v: found %v", ptr, def.ptr))
+				return Value{}, newError(ptr, xref.offset, "found object %d %d", def.ptr.id, def.ptr.gen)
 			}
 			x = def.obj
 		}
+		r.cache.put(ptr, x)
 		parent = ptr
 	}
 
 	switch x := x.(type) {
 	case nil, bool, int64, float64, name, dict, array, stream:
-		return Value{r, parent, x}
+		return Value{r, parent, x}, nil
 	case string:
-		return Value{r, parent, x}
+		return Value{r, parent, x}, nil
 	default:
-		// panic(fmt.Errorf("unexpected value type %T in resolve", x))
-		fmt.Sprintf("unexpected value type %T in resolve", x)
-		return Value{}
+		return Value{}, newError(parent, -1, "unexpected value type %T", x)
+	}
+}
+
+// readInStream reads the object ptr stored in the object stream strmptr,
+// following Extends chains.
+func (r *Reader) readInStream(parent, ptr, strmptr objptr) (object, error) {
+	strm, err := r.resolveErr(parent, strmptr)
+	if err != nil {
+		return nil, err
+	}
+	for seen := map[objptr]bool{}; ; {
+		if strm.Kind() != Stream {
+			return nil, newError(strmptr, -1, "object %d %d: not a stream", ptr.id, ptr.gen)
+		}
+		if seen[strm.ptr] {
+			return nil, newError(strm.ptr, -1, "object stream Extends loop")
+		}
+		seen[strm.ptr] = true
+		s, err := r.objStm(strm)
+		if err != nil {
+			return nil, err
+		}
+		if off, ok := s.offsets[ptr.id]; ok {
+			return s.read(off)
+		}
+		if s.extends.Kind() != Stream {
+			return nil, newError(strm.ptr, -1, "cannot find object %d %d in object stream", ptr.id, ptr.gen)
+		}
+		strm = s.extends
 	}
 }
 
@@ -821,299 +989,57 @@ func (e *errorReadCloser) Close() error {
 // If v.Kind() != Stream, Reader returns a ReadCloser that
 // responds to all reads with a ``stream not present'' error.
 func (v Value) Reader() io.ReadCloser {
+	rd, err := v.ReaderErr()
+	if err != nil {
+		v.r.report(err)
+		return &errorReadCloser{err}
+	}
+	return rd
+}
+
+// ReaderErr is like Reader but returns an error if v is not a stream
+// or if its filters cannot be applied.
+func (v Value) ReaderErr() (io.ReadCloser, error) {
 	x, ok := v.data.(stream)
 	if !ok {
-		return &errorReadCloser{fmt.Errorf("stream not present")}
+		return nil, newError(v.ptr, -1, "stream not present")
+	}
+	if v.r.closed {
+		return nil, &Error{Obj: x.ptr.id, Gen: x.ptr.gen, Offset: x.offset, Err: ErrClosed}
 	}
 	var rd io.Reader
 	rd = io.NewSectionReader(v.r.f, x.offset, v.Key("Length").Int64())
-	if v.r.key != nil {
-		rd = decryptStream(v.r.key, v.r.useAES, x.ptr, rd)
-	}
 	filter := v.Key("Filter")
 	param := v.Key("DecodeParms")
+	if v.r.crypt != nil {
+		m, err := v.r.crypt.streamMethod(v, filter, param)
+		if err != nil {
+			return nil, wrapError(x.ptr, x.offset, err)
+		}
+		rd = decryptStream(v.r.crypt.key, m, x.ptr, rd)
+	}
+	var err error
 	switch filter.Kind() {
 	default:
-		panic(fmt.Errorf("unsupported filter %v", filter))
+		err = fmt.Errorf("unsupported filter %v", filter)
 	case Null:
 		// ok
 	case Name:
-		rd = applyFilter(rd, filter.Name(), param)
+		rd, err = applyFilter(rd, filter.Name(), param)
 	case Array:
-		for i := 0; i < filter.Len(); i++ {
-			rd = applyFilter(rd, filter.Index(i).Name(), param.Index(i))
-		}
-	}
-
-	return ioutil.NopCloser(rd)
-}
-
-func applyFilter(rd io.Reader, name string, param Value) io.Reader {
-	switch name {
-	default:
-		panic("unknown filter " + name)
-	case "FlateDecode":
-		zr, err := zlib.NewReader(rd)
-		if err != nil {
-			panic(err)
-		}
-		pred := param.Key("Predictor")
-		if pred.Kind() == Null {
-			return zr
-		}
-		columns := param.Key("Columns").Int64()
-		switch pred.Int64() {
-		default:
-			fmt.Println("unknown predictor", pred)
-			panic("pred")
-		case 12:
-			return &pngUpReader{r: zr, hist: make([]byte, 1+columns), tmp: make([]byte, 1+columns)}
-		}
-	case "ASCII85Decode":
-		cleanASCII85 := newAlphaReader(rd)
-		decoder := ascii85.NewDecoder(cleanASCII85)
-
-		switch param.Keys() {
-		default:
-			panic("not expected DecodeParms for ascii85")
-		case nil:
-			return decoder
-		}
-	}
-}
-
-type pngUpReader struct {
-	r    io.Reader
-	hist []byte
-	tmp  []byte
-	pend []byte
-}
-
-func (r *pngUpReader) Read(b []byte) (int, error) {
-	n := 0
-	for len(b) > 0 {
-		if len(r.pend) > 0 {
-			m := copy(b, r.pend)
-			n += m
-			b = b[m:]
-			r.pend = r.pend[m:]
-			continue
-		}
-		_, err := io.ReadFull(r.r, r.tmp)
-		if err != nil {
-			return n, err
-		}
-		if r.tmp[0] != 2 {
-			return n, fmt.Errorf("malformed PNG-Up encoding")
-		}
-		for i, b := range r.tmp {
-			r.hist[i] += b
-		}
-		r.pend = r.hist[1:]
-	}
-	return n, nil
-}
-
-var passwordPad = []byte{
-	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
-	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
-}
-
-func (r *Reader) initEncrypt(password string) error {
-	// See PDF 32000-1:2008, §7.6.
-	encrypt, _ := r.resolve(objptr{}, r.trailer["Encrypt"]).data.(dict)
-	if encrypt["Filter"] != name("Standard") {
-		return fmt.Errorf("unsupported PDF: encryption filter %v", objfmt(encrypt["Filter"]))
-	}
-	n, _ := encrypt["Length"].(int64)
-	if n == 0 {
-		n = 40
-	}
-	if n%8 != 0 || n > 128 || n < 40 {
-		return fmt.Errorf("malformed PDF: %d-bit encryption key", n)
-	}
-	V, _ := encrypt["V"].(int64)
-	if V != 1 && V != 2 && (V != 4 || !okayV4(encrypt)) {
-		return fmt.Errorf("unsupported PDF: encryption version V=%d; %v", V, objfmt(encrypt))
-	}
-
-	ids, ok := r.trailer["ID"].(array)
-	if !ok || len(ids) < 1 {
-		return fmt.Errorf("malformed PDF: missing ID in trailer")
-	}
-	idstr, ok := ids[0].(string)
-	if !ok {
-		return fmt.Errorf("malformed PDF: missing ID in trailer")
-	}
-	ID := []byte(idstr)
-
-	R, _ := encrypt["R"].(int64)
-	if R < 2 {
-		return fmt.Errorf("malformed PDF: encryption revision R=%d", R)
-	}
-	if R > 4 {
-		return fmt.Errorf("unsupported PDF: encryption revision R=%d", R)
-	}
-	O, _ := encrypt["O"].(string)
-	U, _ := encrypt["U"].(string)
-	if len(O) != 32 || len(U) != 32 {
-		return fmt.Errorf("malformed PDF: missing O= or U= encryption parameters")
-	}
-	p, _ := encrypt["P"].(int64)
-	P := uint32(p)
-
-	// TODO: Password should be converted to Latin-1.
-	pw := []byte(password)
-	h := md5.New()
-	if len(pw) >= 32 {
-		h.Write(pw[:32])
-	} else {
-		h.Write(pw)
-		h.Write(passwordPad[:32-len(pw)])
-	}
-	h.Write([]byte(O))
-	h.Write([]byte{byte(P), byte(P >> 8), byte(P >> 16), byte(P >> 24)})
-	h.Write([]byte(ID))
-	key := h.Sum(nil)
-
-	if R >= 3 {
-		for i := 0; i < 50; i++ {
-			h.Reset()
-			h.Write(key[:n/8])
-			key = h.Sum(key[:0])
-		}
-		key = key[:n/8]
-	} else {
-		key = key[:40/8]
-	}
-
-	c, err := rc4.NewCipher(key)
-	if err != nil {
-		return fmt.Errorf("malformed PDF: invalid RC4 key: %v", err)
-	}
-
-	var u []byte
-	if R == 2 {
-		u = make([]byte, 32)
-		copy(u, passwordPad)
-		c.XORKeyStream(u, u)
-	} else {
-		h.Reset()
-		h.Write(passwordPad)
-		h.Write([]byte(ID))
-		u = h.Sum(nil)
-		c.XORKeyStream(u, u)
-
-		for i := 1; i <= 19; i++ {
-			key1 := make([]byte, len(key))
-			copy(key1, key)
-			for j := range key1 {
-				key1[j] ^= byte(i)
+		// DecodeParms is an array parallel to Filter, but writers
+		// sometimes give a single dictionary for a one-element chain.
+		for i := 0; i < filter.Len() && err == nil; i++ {
+			p := param.Index(i)
+			if param.Kind() == Dict && filter.Len() == 1 {
+				p = param
 			}
-			c, _ = rc4.NewCipher(key1)
-			c.XORKeyStream(u, u)
+			rd, err = applyFilter(rd, filter.Index(i).Name(), p)
 		}
 	}
-
-	if !bytes.HasPrefix([]byte(U), u) {
-		return ErrInvalidPassword
-	}
-
-	r.key = key
-	r.useAES = V == 4
-
-	return nil
-}
-
-var ErrInvalidPassword = fmt.Errorf("encrypted PDF: invalid password")
-
-func okayV4(encrypt dict) bool {
-	cf, ok := encrypt["CF"].(dict)
-	if !ok {
-		return false
-	}
-	stmf, ok := encrypt["StmF"].(name)
-	if !ok {
-		return false
-	}
-	strf, ok := encrypt["StrF"].(name)
-	if !ok {
-		return false
-	}
-	if stmf != strf {
-		return false
-	}
-	cfparam, ok := cf[stmf].(dict)
-	if cfparam["AuthEvent"] != nil && cfparam["AuthEvent"] != name("DocOpen") {
-		return false
-	}
-	if cfparam["Length"] != nil && cfparam["Length"] != int64(16) {
-		return false
-	}
-	if cfparam["CFM"] != name("AESV2") {
-		return false
-	}
-	return true
-}
-
-func cryptKey(key []byte, useAES bool, ptr objptr) []byte {
-	h := md5.New()
-	h.Write(key)
-	h.Write([]byte{byte(ptr.id), byte(ptr.id >> 8), byte(ptr.id >> 16), byte(ptr.gen), byte(ptr.gen >> 8)})
-	if useAES {
-		h.Write([]byte("sAlT"))
-	}
-	return h.Sum(nil)
-}
-
-func decryptString(key []byte, useAES bool, ptr objptr, x string) string {
-	key = cryptKey(key, useAES, ptr)
-	if useAES {
-		panic("AES not implemented")
-	} else {
-		c, _ := rc4.NewCipher(key)
-		data := []byte(x)
-		c.XORKeyStream(data, data)
-		x = string(data)
-	}
-	return x
-}
-
-func decryptStream(key []byte, useAES bool, ptr objptr, rd io.Reader) io.Reader {
-	key = cryptKey(key, useAES, ptr)
-	if useAES {
-		cb, err := aes.NewCipher(key)
-		if err != nil {
-			panic("AES: " + err.Error())
-		}
-		iv := make([]byte, 16)
-		io.ReadFull(rd, iv)
-		cbc := cipher.NewCBCDecrypter(cb, iv)
-		rd = &cbcReader{cbc: cbc, rd: rd, buf: make([]byte, 16)}
-	} else {
-		c, _ := rc4.NewCipher(key)
-		rd = &cipher.StreamReader{S: c, R: rd}
+	if err != nil {
+		return nil, wrapError(x.ptr, x.offset, err)
 	}
-	return rd
-}
 
-type cbcReader struct {
-	cbc  cipher.BlockMode
-	rd   io.Reader
-	buf  []byte
-	pend []byte
-}
-
-func (r *cbcReader) Read(b []byte) (n int, err error) {
-	if len(r.pend) == 0 {
-		_, err = io.ReadFull(r.rd, r.buf)
-		if err != nil {
-			return 0, err
-		}
-		r.cbc.CryptBlocks(r.buf, r.buf)
-		r.pend = r.buf
-	}
-	n = copy(b, r.pend)
-	r.pend = r.pend[n:]
-	return n, nil
+	return ioutil.NopCloser(rd), nil
 }
@@ -0,0 +1,178 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Repaired returns the error that made the Reader rebuild the
+// cross-reference table by scanning the file, or nil if the file's own
+// table was used. A repaired file may be missing objects that the damaged
+// table would have led to.
+func (r *Reader) Repaired() error {
+	return r.repaired
+}
+
+var (
+	objHeader     = regexp.MustCompile(`(?:\A|[\x00\t\n\f\r ])(\d{1,10})[\x00\t\n\f\r ]+(\d{1,5})[\x00\t\n\f\r ]+obj\b`)
+	trailerHeader = regexp.MustCompile(`trailer[\x00\t\n\f\r ]*<<`)
+)
+
+// A trailerCand is a trailer dictionary found while rebuilding the table.
+type trailerCand struct {
+	offset int64
+	dict   dict
+}
+
+// rebuildXref reconstructs the cross-reference table by scanning the whole
+// file for "N G obj" headers and trailer dictionaries, for files whose
+// startxref or xref offsets are wrong or whose trailer is missing.
+// When an object is defined more than once, the last definition that
+// parses wins, as it would after incremental updates.
+//
+// Objects in object streams are added by finishRepair, once the
+// file can be decrypted.
+func (r *Reader) rebuildXref() error {
+	r.cache.reset()
+	r.objstm.reset()
+	r.metrics.reset()
+	data := make([]byte, r.end)
+	n, err := r.f.ReadAt(data, 0)
+	if n < len(data) && err != nil && err != io.EOF {
+		return err
+	}
+	data = data[:n]
+
+	var cands [][]xref
+	for _, m := range objHeader.FindAllSubmatchIndex(data, -1) {
+		id, err1 := strconv.ParseUint(string(data[m[2]:m[3]]), 10, 32)
+		gen, err2 := strconv.ParseUint(string(data[m[4]:m[5]]), 10, 16)
+		if err1 != nil || err2 != nil || id == 0 {
+			continue
+		}
+		for uint64(len(cands)) <= id {
+			cands = append(cands, nil)
+		}
+		cands[id] = append(cands[id], xref{ptr: objptr{uint32(id), uint16(gen)}, offset: int64(m[2])})
+	}
+	if len(cands) == 0 {
+		return newError(objptr{}, -1, "no objects found")
+	}
+
+	var trailers []trailerCand
+	var encrypt objptr
+	r.xref = make([]xref, len(cands))
+	for id, list := range cands {
+		for i := len(list) - 1; i >= 0; i-- {
+			r.xref[id] = list[i]
+			v, err := r.resolveErr(objptr{}, list[i].ptr)
+			if err == nil {
+				switch {
+				case v.Kind() == Stream && v.Key("Type").Name() == "XRef":
+					trailers = append(trailers, trailerCand{list[i].offset, v.data.(stream).hdr})
+				case v.Kind() == Dict && v.Key("Filter").Name() == "Standard" && v.Key("O").Kind() == String:
+					encrypt = list[i].ptr
+				}
+				break
+			}
+			r.xref[id] = xref{}
+		}
+	}
+
+	for _, m := range trailerHeader.FindAllIndex(data, -1) {
+		off := int64(m[1] - 2)
+		b := newBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
+		b.allowEOF = true
+		b.allowStream = false
+		if b.readToken() != keyword("<<") {
+			continue
+		}
+		if d, ok := b.readDict().(dict); ok {
+			trailers = append(trailers, trailerCand{off, d})
+		}
+	}
+
+	// Later trailers describe later revisions of the file.
+	sort.SliceStable(trailers, func(i, j int) bool {
+		return trailers[i].offset < trailers[j].offset
+	})
+	r.trailer = dict{"Size": int64(len(r.xref))}
+	for _, t := range trailers {
+		for _, key := range []name{"Root", "Info", "Encrypt", "ID"} {
+			if x, ok := t.dict[key]; ok {
+				r.trailer[key] = x
+			}
+		}
+	}
+	if r.trailer["Encrypt"] == nil && encrypt.id != 0 {
+		// Without the trailer's ID, only AES-256 files can be opened,
+		// but failing to decrypt is better than returning ciphertext.
+		r.trailer["Encrypt"] = encrypt
+	}
+	r.trailerptr = objptr{}
+
+	// Nothing parsed so far was decrypted, and the syntax errors met
+	// while trying candidates are summarized by Repaired.
+	r.cache.reset()
+	r.objstm.reset()
+	r.metrics.reset()
+	r.err = nil
+	return nil
+}
+
+// finishRepair completes a rebuilt table with the objects stored in
+// object streams and, if the trailers did not name a usable catalog,
+// takes the highest-numbered /Type /Catalog object as the Root.
+func (r *Reader) finishRepair() error {
+	defer func() { r.err = nil }()
+	direct := len(r.xref)
+	for id := 0; id < direct; id++ {
+		x := r.xref[id]
+		if x.offset == 0 {
+			continue
+		}
+		v, err := r.resolveErr(objptr{}, x.ptr)
+		if err != nil || v.Kind() != Stream || v.Key("Type").Name() != "ObjStm" {
+			continue
+		}
+		s, err := r.objStm(v)
+		if err != nil {
+			continue
+		}
+		for id := range s.offsets {
+			for uint32(len(r.xref)) <= id {
+				r.xref = append(r.xref, xref{})
+			}
+			if id == 0 || r.xref[id].offset != 0 || r.xref[id].inStream {
+				continue
+			}
+			r.xref[id] = xref{ptr: objptr{id, 0}, inStream: true, stream: x.ptr}
+		}
+	}
+	r.trailer["Size"] = int64(len(r.xref))
+
+	if r.checkCatalog() == nil {
+		return nil
+	}
+	for id := len(r.xref) - 1; id > 0; id-- {
+		x := r.xref[id]
+		if x.offset == 0 && !x.inStream {
+			continue
+		}
+		v, err := r.resolveErr(objptr{}, x.ptr)
+		if err == nil && v.Kind() == Dict && v.Key("Type").Name() == "Catalog" {
+			r.trailer["Root"] = x.ptr
+			if r.checkCatalog() == nil {
+				return nil
+			}
+		}
+	}
+	delete(r.trailer, "Root")
+	return r.checkCatalog()
+}
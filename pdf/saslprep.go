@@ -0,0 +1,230 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// saslPrep prepares an AES-256 password with the SASLprep profile
+// of stringprep (RFC 4013) and returns it as UTF-8.
+//
+// The tables in saslprep.txt are those of RFC 3454, and normalization
+// is NFKC as of Unicode 3.2, which stringprep is defined against.
+// A password typed to open a file is a query in stringprep's terms,
+// so unassigned code points (table A.1) are let through.
+func saslPrep(password string) ([]byte, error) {
+	saslOnce.Do(loadSASLTables)
+
+	var mapped []rune
+	for _, r := range password {
+		switch {
+		case sasl.nothing.has(r):
+			continue
+		case sasl.space.has(r):
+			r = ' '
+		}
+		mapped = append(mapped, r)
+	}
+	s := nfkc(mapped)
+
+	hasRAL, hasL := false, false
+	for _, r := range s {
+		if sasl.prohibit.has(r) {
+			return nil, fmt.Errorf("encrypted PDF: password contains prohibited character %U", r)
+		}
+		hasRAL = hasRAL || sasl.randAL.has(r)
+		hasL = hasL || sasl.l.has(r)
+	}
+	if hasRAL && (hasL || !sasl.randAL.has(s[0]) || !sasl.randAL.has(s[len(s)-1])) {
+		return nil, fmt.Errorf("encrypted PDF: password mixes right-to-left and left-to-right text")
+	}
+	return []byte(string(s)), nil
+}
+
+// A runeSet is a sorted list of disjoint code point ranges.
+type runeSet [][2]rune
+
+func (s runeSet) has(r rune) bool {
+	i := sort.Search(len(s), func(i int) bool { return s[i][1] >= r })
+	return i < len(s) && s[i][0] <= r
+}
+
+//go:embed saslprep.txt
+var saslprepText string
+
+var (
+	saslOnce sync.Once
+	sasl     struct {
+		nothing, space, prohibit, randAL, l runeSet
+
+		ccc     map[rune]uint8
+		decomp  map[rune][]rune
+		compose map[[2]rune]rune
+	}
+)
+
+func loadSASLTables() {
+	sasl.ccc = make(map[rune]uint8)
+	sasl.decomp = make(map[rune][]rune)
+	sasl.compose = make(map[[2]rune]rune)
+	for _, line := range strings.Split(saslprepText, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Split(line, "\t")
+		bad := func() {
+			panic("pdf: malformed saslprep.txt: " + line)
+		}
+		if len(f) < 2 {
+			bad()
+		}
+		switch f[0] {
+		case "nothing", "space", "prohibit", "randal", "l":
+			set := map[string]*runeSet{
+				"nothing":  &sasl.nothing,
+				"space":    &sasl.space,
+				"prohibit": &sasl.prohibit,
+				"randal":   &sasl.randAL,
+				"l":        &sasl.l,
+			}[f[0]]
+			for _, field := range strings.Fields(f[1]) {
+				lo, hi, ok := parseRuneRange(field)
+				if !ok {
+					bad()
+				}
+				*set = append(*set, [2]rune{lo, hi})
+			}
+		case "ccc":
+			class, err := strconv.Atoi(f[1])
+			if len(f) != 3 || err != nil || class <= 0 || class > 255 {
+				bad()
+			}
+			for _, field := range strings.Fields(f[2]) {
+				lo, hi, ok := parseRuneRange(field)
+				if !ok {
+					bad()
+				}
+				for r := lo; r <= hi; r++ {
+					sasl.ccc[r] = uint8(class)
+				}
+			}
+		case "decomp", "compose":
+			if len(f) != 3 {
+				bad()
+			}
+			r, ok := parseRune(f[1])
+			var seq []rune
+			for _, field := range strings.Fields(f[2]) {
+				x, ok1 := parseRune(field)
+				ok = ok && ok1
+				seq = append(seq, x)
+			}
+			switch {
+			case !ok || len(seq) == 0:
+				bad()
+			case f[0] == "decomp":
+				sasl.decomp[r] = seq
+			case len(seq) != 2:
+				bad()
+			default:
+				sasl.compose[[2]rune{seq[0], seq[1]}] = r
+			}
+		default:
+			bad()
+		}
+	}
+}
+
+func parseRune(s string) (rune, bool) {
+	n, err := strconv.ParseUint(s, 16, 32)
+	return rune(n), err == nil && n <= 0x10FFFF
+}
+
+func parseRuneRange(s string) (lo, hi rune, ok bool) {
+	a, b, found := strings.Cut(s, "-")
+	if !found {
+		b = a
+	}
+	lo, ok1 := parseRune(a)
+	hi, ok2 := parseRune(b)
+	return lo, hi, ok1 && ok2 && lo <= hi
+}
+
+// Hangul syllable arithmetic (Unicode 3.2, section 3.12).
+const (
+	hangulS = 0xAC00
+	hangulL = 0x1100
+	hangulV = 0x1161
+	hangulT = 0x11A7
+	countL  = 19
+	countV  = 21
+	countT  = 28
+	countS  = countL * countV * countT
+)
+
+// nfkc returns s in Normalization Form KC: the full compatibility
+// decomposition, put in canonical order, then canonically composed.
+func nfkc(s []rune) []rune {
+	var d []rune
+	for _, r := range s {
+		switch {
+		case r >= hangulS && r < hangulS+countS:
+			i := r - hangulS
+			d = append(d, hangulL+i/(countV*countT), hangulV+i%(countV*countT)/countT)
+			if t := i % countT; t != 0 {
+				d = append(d, hangulT+t)
+			}
+		case sasl.decomp[r] != nil:
+			d = append(d, sasl.decomp[r]...)
+		default:
+			d = append(d, r)
+		}
+	}
+
+	// Sort each run of combining marks by class, keeping equal classes in order.
+	for i := 1; i < len(d); i++ {
+		for j := i; j > 0 && sasl.ccc[d[j]] != 0 && sasl.ccc[d[j-1]] > sasl.ccc[d[j]]; j-- {
+			d[j-1], d[j] = d[j], d[j-1]
+		}
+	}
+
+	// Compose each character with the last starter unless a character
+	// in between blocks it: one of class zero or of at least its class.
+	out := d[:0]
+	starter := -1
+	var last uint8
+	for _, r := range d {
+		class := sasl.ccc[r]
+		if starter >= 0 && (len(out)-1 == starter || last < class) {
+			if c, ok := composePair(out[starter], r); ok {
+				out[starter] = c
+				continue
+			}
+		}
+		if class == 0 {
+			starter = len(out)
+		}
+		out = append(out, r)
+		last = class
+	}
+	return out
+}
+
+func composePair(a, b rune) (rune, bool) {
+	switch {
+	case a >= hangulL && a < hangulL+countL && b >= hangulV && b < hangulV+countV:
+		return hangulS + ((a-hangulL)*countV+b-hangulV)*countT, true
+	case a >= hangulS && a < hangulS+countS && (a-hangulS)%countT == 0 && b > hangulT && b < hangulT+countT:
+		return a + b - hangulT, true
+	}
+	c, ok := sasl.compose[[2]rune{a, b}]
+	return c, ok
+}
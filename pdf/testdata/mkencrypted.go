@@ -0,0 +1,319 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build ignore
+
+// Mkencrypted writes the encrypted PDF files that crypt_test.go opens.
+// It implements the writing side of the standard security handler
+// independently of package pdf, using only the standard library.
+//
+// Usage:
+//
+//	cd testdata && go run mkencrypted.go
+//
+// Every file has one page showing "secret text", an Info dictionary
+// titled "Secret Title" and a Metadata stream holding "plain metadata".
+// The user password is "user" (or empty), the owner password "owner".
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"os"
+)
+
+var pad = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+func padpw(pw string) []byte { b := append([]byte(pw), pad...); return b[:32] }
+
+func rc4x(key, data []byte) []byte {
+	c, _ := rc4.NewCipher(key)
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out
+}
+
+type spec struct {
+	file        string
+	V, R        int
+	bits        int    // key length
+	cfm         string // method of StdCF for V4 and V5: V2, AESV2 or AESV3
+	stmf, strf  string // StmF and StrF for V4 and V5: StdCF or Identity
+	meta        bool   // EncryptMetadata
+	upw, opw    string // already prepared with SASLprep for V5
+	identityStm bool   // the content stream names the Identity filter itself
+}
+
+var specs = []spec{
+	{file: "rc4-40.pdf", V: 1, R: 2, bits: 40, meta: true, upw: "user", opw: "owner"},
+	{file: "rc4-128.pdf", V: 2, R: 3, bits: 128, meta: true, upw: "user", opw: "owner"},
+	{file: "rc4-v4.pdf", V: 4, R: 4, bits: 128, cfm: "V2", stmf: "StdCF", strf: "StdCF", meta: true, upw: "user", opw: "owner"},
+	{file: "aes128.pdf", V: 4, R: 4, bits: 128, cfm: "AESV2", stmf: "StdCF", strf: "StdCF", meta: true, upw: "", opw: "owner"},
+	{file: "aes128-nometa.pdf", V: 4, R: 4, bits: 128, cfm: "AESV2", stmf: "StdCF", strf: "StdCF", meta: false, upw: "user", opw: "owner"},
+	{file: "aes128-strf-identity.pdf", V: 4, R: 4, bits: 128, cfm: "AESV2", stmf: "StdCF", strf: "Identity", meta: true, upw: "", opw: "owner", identityStm: true},
+	{file: "aes128-stmf-identity.pdf", V: 4, R: 4, bits: 128, cfm: "AESV2", stmf: "Identity", strf: "StdCF", meta: true, upw: "user", opw: "owner"},
+	{file: "aes256-r5.pdf", V: 5, R: 5, bits: 256, cfm: "AESV3", stmf: "StdCF", strf: "StdCF", meta: true, upw: "user", opw: "owner"},
+	{file: "aes256-r6.pdf", V: 5, R: 6, bits: 256, cfm: "AESV3", stmf: "StdCF", strf: "StdCF", meta: true, upw: "user", opw: "owner"},
+	{file: "aes256-r6-nometa.pdf", V: 5, R: 6, bits: 256, cfm: "AESV3", stmf: "StdCF", strf: "StdCF", meta: false, upw: "", opw: "owner"},
+	{file: "aes256-r6-saslprep.pdf", V: 5, R: 6, bits: 256, cfm: "AESV3", stmf: "StdCF", strf: "StdCF", meta: true, upw: "passガ", opw: "owner"},
+}
+
+var id = []byte("0123456789abcdef")
+
+const P = -3904
+
+func main() {
+	for _, s := range specs {
+		if err := os.WriteFile(s.file, build(s), 0666); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func fileKeyRC4(s spec, O []byte) []byte {
+	n := s.bits / 8
+	if s.R == 2 {
+		n = 5
+	}
+	h := md5.New()
+	h.Write(padpw(s.upw))
+	h.Write(O)
+	binary.Write(h, binary.LittleEndian, int32(P))
+	h.Write(id)
+	if s.R >= 4 && !s.meta {
+		h.Write([]byte{255, 255, 255, 255})
+	}
+	k := h.Sum(nil)
+	if s.R >= 3 {
+		for i := 0; i < 50; i++ {
+			x := md5.Sum(k[:n])
+			k = x[:]
+		}
+	}
+	return k[:n]
+}
+
+func ownerRC4(s spec) []byte {
+	n := s.bits / 8
+	if s.R == 2 {
+		n = 5
+	}
+	x := md5.Sum(padpw(s.opw))
+	if s.R >= 3 {
+		for i := 0; i < 50; i++ {
+			x = md5.Sum(x[:])
+		}
+	}
+	k := x[:n]
+	o := rc4x(k, padpw(s.upw))
+	if s.R >= 3 {
+		for i := 1; i <= 19; i++ {
+			k1 := make([]byte, n)
+			for j := range k1 {
+				k1[j] = k[j] ^ byte(i)
+			}
+			o = rc4x(k1, o)
+		}
+	}
+	return o
+}
+
+func userRC4(s spec, key []byte) []byte {
+	if s.R == 2 {
+		return rc4x(key, pad)
+	}
+	h := md5.New()
+	h.Write(pad)
+	h.Write(id)
+	u := rc4x(key, h.Sum(nil))
+	for i := 1; i <= 19; i++ {
+		k1 := make([]byte, len(key))
+		for j := range k1 {
+			k1[j] = key[j] ^ byte(i)
+		}
+		u = rc4x(k1, u)
+	}
+	return append(u, make([]byte, 16)...)
+}
+
+func h6(R int, pw, salt, udata []byte) []byte {
+	h := sha256.New()
+	h.Write(pw)
+	h.Write(salt)
+	h.Write(udata)
+	k := h.Sum(nil)
+	if R == 5 {
+		return k
+	}
+	round := 0
+	for {
+		var k1 []byte
+		for j := 0; j < 64; j++ {
+			k1 = append(append(append(k1, pw...), k...), udata...)
+		}
+		b, _ := aes.NewCipher(k[:16])
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(b, k[16:32]).CryptBlocks(e, k1)
+		var sum int
+		for _, c := range e[:16] {
+			sum += int(c)
+		}
+		var hh hash.Hash
+		switch sum % 3 {
+		case 0:
+			hh = sha256.New()
+		case 1:
+			hh = sha512.New384()
+		default:
+			hh = sha512.New()
+		}
+		hh.Write(e)
+		k = hh.Sum(nil)
+		round++
+		if round >= 64 && int(e[len(e)-1]) <= round-32 {
+			break
+		}
+	}
+	return k[:32]
+}
+
+func aesNoIV(key, data []byte) []byte {
+	b, _ := aes.NewCipher(key)
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(b, make([]byte, 16)).CryptBlocks(out, data)
+	return out
+}
+
+func objKey(s spec, key []byte, num int, aesm bool) []byte {
+	if s.V == 5 {
+		return key
+	}
+	h := md5.New()
+	h.Write(key)
+	h.Write([]byte{byte(num), byte(num >> 8), byte(num >> 16), 0, 0})
+	if aesm {
+		h.Write([]byte("sAlT"))
+	}
+	n := len(key) + 5
+	if n > 16 {
+		n = 16
+	}
+	return h.Sum(nil)[:n]
+}
+
+func enc(s spec, key []byte, num int, data []byte, method string) []byte {
+	switch method {
+	case "Identity":
+		return data
+	case "V2":
+		return rc4x(objKey(s, key, num, false), data)
+	}
+	k := objKey(s, key, num, true)
+	b, _ := aes.NewCipher(k)
+	n := 16 - len(data)%16
+	p := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(n)}, n)...)
+	iv := []byte("fedcba9876543210")
+	out := make([]byte, len(p))
+	cipher.NewCBCEncrypter(b, iv).CryptBlocks(out, p)
+	return append(iv, out...)
+}
+
+func build(s spec) []byte {
+	var key, O, U, OE, UE, perms []byte
+	if s.V == 5 {
+		key = []byte("0123456789ABCDEF0123456789abcdef")
+		uv, uk := []byte("uvalsalt"), []byte("ukeysalt")
+		U = append(append(h6(s.R, []byte(s.upw), uv, nil), uv...), uk...)
+		UE = aesNoIV(h6(s.R, []byte(s.upw), uk, nil), key)
+		ov, ok := []byte("ovalsalt"), []byte("okeysalt")
+		O = append(append(h6(s.R, []byte(s.opw), ov, U), ov...), ok...)
+		OE = aesNoIV(h6(s.R, []byte(s.opw), ok, U), key)
+		p := make([]byte, 16)
+		pv := int32(P)
+		binary.LittleEndian.PutUint32(p, uint32(pv))
+		copy(p[4:], []byte{255, 255, 255, 255})
+		if s.meta {
+			p[8] = 'T'
+		} else {
+			p[8] = 'F'
+		}
+		copy(p[9:], "adbwxyz")
+		b, _ := aes.NewCipher(key)
+		perms = make([]byte, 16)
+		b.Encrypt(perms, p)
+	} else {
+		O = ownerRC4(s)
+		key = fileKeyRC4(s, O)
+		U = userRC4(s, key)
+	}
+	stmMethod, strMethod := "V2", "V2"
+	if s.V >= 4 {
+		stmMethod, strMethod = s.cfm, s.cfm
+		if s.stmf == "Identity" {
+			stmMethod = "Identity"
+		}
+		if s.strf == "Identity" {
+			strMethod = "Identity"
+		}
+	}
+
+	hexs := func(b []byte) string { return fmt.Sprintf("<%x>", b) }
+	content := []byte("BT /F1 12 Tf 72 700 Td (secret text) Tj ET")
+	meta := []byte("<x:xmpmeta>plain metadata</x:xmpmeta>")
+	var objs []string
+	add := func(o string) int { objs = append(objs, o); return len(objs) }
+	add("<< /Type /Catalog /Pages 2 0 R /Metadata 6 0 R >>")
+	add("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	add("<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>")
+	cstm := enc(s, key, 4, content, stmMethod)
+	cdict := ""
+	if s.identityStm {
+		cstm = content
+		cdict = " /Filter [/Crypt] /DecodeParms [<< /Name /Identity >>]"
+	}
+	add(fmt.Sprintf("<< /Length %d%s >>\nstream\n%s\nendstream", len(cstm), cdict, cstm))
+	add(fmt.Sprintf("<< /Title %s >>", hexs(enc(s, key, 5, []byte("Secret Title"), strMethod))))
+	mstm := meta
+	if s.meta {
+		mstm = enc(s, key, 6, meta, stmMethod)
+	}
+	add(fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(mstm), mstm))
+	var e string
+	switch s.V {
+	case 1, 2:
+		e = fmt.Sprintf("<< /Filter /Standard /V %d /R %d /Length %d /O %s /U %s /P %d >>", s.V, s.R, s.bits, hexs(O), hexs(U), P)
+	case 4:
+		e = fmt.Sprintf("<< /Filter /Standard /V 4 /R 4 /Length 128 /CF << /StdCF << /CFM /%s /AuthEvent /DocOpen /Length 16 >> >> /StmF /%s /StrF /%s /O %s /U %s /P %d /EncryptMetadata %v >>", s.cfm, s.stmf, s.strf, hexs(O), hexs(U), P, s.meta)
+	case 5:
+		e = fmt.Sprintf("<< /Filter /Standard /V 5 /R %d /Length 256 /CF << /StdCF << /CFM /AESV3 /AuthEvent /DocOpen /Length 32 >> >> /StmF /StdCF /StrF /StdCF /O %s /U %s /OE %s /UE %s /Perms %s /P %d /EncryptMetadata %v >>", s.R, hexs(O), hexs(U), hexs(OE), hexs(UE), hexs(perms), P, s.meta)
+	}
+	encNum := add(e)
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.7\n")
+	var offs []int
+	for i, o := range objs {
+		offs = append(offs, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	x := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, o := range offs {
+		fmt.Fprintf(&out, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R /Encrypt %d 0 R /ID [%s %s] >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, encNum, hexs(id), hexs(id), x)
+	return out.Bytes()
+}
@@ -0,0 +1,139 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+// maxFormDepth limits the nesting of Form XObjects, so that a file whose
+// forms draw each other through ever new objects cannot recurse forever.
+const maxFormDepth = 32
+
+// A resources is a resource dictionary along with the fonts looked up in it.
+type resources struct {
+	v       Value
+	fonts   map[string]*Font
+	encs    map[string]TextEncoding
+	metrics map[string]*fontMetrics
+}
+
+func newResources(v Value) *resources {
+	return &resources{
+		v:       v,
+		fonts:   make(map[string]*Font),
+		encs:    make(map[string]TextEncoding),
+		metrics: make(map[string]*fontMetrics),
+	}
+}
+
+// font returns the font with the given name.
+func (r *resources) font(name string) *Font {
+	f, ok := r.fonts[name]
+	if !ok {
+		f = &Font{r.v.Key("Font").Key(name), nil}
+		r.fonts[name] = f
+	}
+	return f
+}
+
+// encoder returns the encoding of the font with the given name,
+// or a nopEncoder if there is no such font.
+func (r *resources) encoder(name string) TextEncoding {
+	enc, ok := r.encs[name]
+	if !ok {
+		if f := r.font(name); f.V.IsNull() {
+			enc = &nopEncoder{}
+		} else {
+			enc = f.Encoder()
+		}
+		r.encs[name] = enc
+	}
+	return enc
+}
+
+// fontMetrics returns the metrics of the font with the given name.
+func (r *resources) fontMetrics(name string) *fontMetrics {
+	m, ok := r.metrics[name]
+	if !ok {
+		m = newFontMetrics(*r.font(name))
+		r.metrics[name] = m
+	}
+	return m
+}
+
+// interpretContent interprets the content stream strm, drawn with the
+// resources res, calling do for each operator as InterpretErr does.
+//
+// A Do operator that paints a Form XObject (PDF 32000-1:2008, §8.10) is
+// not passed to do. Instead the form's own content stream is interpreted
+// with the form's resources, or res if it has none. If form is not nil, it
+// is called with the XObject before its content and the function it
+// returns is called after; callers use them to apply the form's /Matrix
+// and to restore their state, as the form is drawn inside a q/Q pair.
+// A form that draws itself, directly or through other forms, is reported
+// to the Reader and skipped.
+func interpretContent(strm Value, res *resources, do func(res *resources, stk *Stack, op string) error, form func(xobj Value) func()) error {
+	active := make(map[objptr]bool)
+	formRes := make(map[objptr]*resources)
+	var walk func(strm Value, res *resources) error
+	walk = func(strm Value, res *resources) error {
+		return InterpretErr(strm, func(stk *Stack, op string) error {
+			if op != "Do" {
+				return do(res, stk, op)
+			}
+			n := stk.Len()
+			args := make([]Value, n)
+			for i := n - 1; i >= 0; i-- {
+				args[i] = stk.Pop()
+			}
+			if len(args) != 1 {
+				return badOperands(op, args)
+			}
+			xobj := res.v.Key("XObject").Key(args[0].Name())
+			if xobj.Kind() != Stream || xobj.Key("Subtype").Name() != "Form" {
+				return nil
+			}
+			if active[xobj.ptr] {
+				strm.r.errorf(xobj.ptr, -1, "Form XObject %s draws itself", args[0].Name())
+				return nil
+			}
+			if len(active) >= maxFormDepth {
+				strm.r.errorf(xobj.ptr, -1, "Form XObjects nested more than %d deep", maxFormDepth)
+				return nil
+			}
+			xres := res
+			if r := xobj.Key("Resources"); r.Kind() == Dict {
+				if xres = formRes[xobj.ptr]; xres == nil {
+					xres = newResources(r)
+					formRes[xobj.ptr] = xres
+				}
+			}
+			var leave func()
+			if form != nil {
+				leave = form(xobj)
+			}
+			active[xobj.ptr] = true
+			err := walk(xobj, xres)
+			delete(active, xobj.ptr)
+			if leave != nil {
+				leave()
+			}
+			return err
+		})
+	}
+	return walk(strm, res)
+}
+
+// formMatrix returns the /Matrix of the Form XObject xobj, which maps
+// form space to the user space of the content that draws it.
+func formMatrix(xobj Value) matrix {
+	m := xobj.Key("Matrix")
+	if m.Len() != 6 {
+		return ident
+	}
+	var x matrix
+	for i := 0; i < 6; i++ {
+		x[i/2][i%2] = m.Index(i).Float64()
+	}
+	x[2][2] = 1
+	return x
+}
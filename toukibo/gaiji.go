@@ -0,0 +1,71 @@
+package toukibo
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"vandal/pdf"
+)
+
+// Gaiji は外字のため読み取れなかった文字の位置
+type Gaiji struct {
+	Field    string // 項目名
+	Position int    // 項目の先頭を0とする文字の位置
+	Rune     rune   // 私用領域の符号位置。対応する文字がなかった場合は U+FFFD
+}
+
+func (g Gaiji) String() string {
+	return fmt.Sprintf("%s: unrepresentable character at position %d", g.Field, g.Position)
+}
+
+// IsGaiji は私用領域の文字か、PDFから文字に変換できなかったグリフであればtrueを返す
+func IsGaiji(r rune) bool {
+	return r == utf8.RuneError || pdf.IsPUA(r)
+}
+
+// FindGaiji は s に含まれる外字の位置を返す
+func FindGaiji(field, s string) []Gaiji {
+	var found []Gaiji
+	pos := 0
+	for _, r := range s {
+		if IsGaiji(r) {
+			found = append(found, Gaiji{Field: field, Position: pos, Rune: r})
+		}
+		pos++
+	}
+	return found
+}
+
+// CheckGaiji は商号、本店、役員などの項目に含まれる外字を Gaiji に記録する
+func (h *Houjin) CheckGaiji() error {
+	h.Gaiji = nil
+	check := func(field, s string) {
+		h.Gaiji = append(h.Gaiji, FindGaiji(field, s)...)
+	}
+	check("商号", h.CompanyName)
+	check("本店", h.CompanyAddress)
+	check("主たる事務所", h.MainOffice)
+	for i, b := range h.BranchOffices {
+		check(fmt.Sprintf("支店[%d]", i), b)
+	}
+	for i, o := range h.Officers {
+		check(fmt.Sprintf("役員[%d].氏名", i), o.Name)
+		check(fmt.Sprintf("役員[%d].住所", i), o.Address)
+	}
+	if h.Foreign != nil {
+		for i, o := range h.Foreign.JapanRepresentatives {
+			check(fmt.Sprintf("日本における代表者[%d].氏名", i), o.Name)
+		}
+	}
+	for i, r := range h.Reorganisations {
+		for j, c := range r.Counterparties {
+			check(fmt.Sprintf("組織再編[%d].相手方[%d]", i, j), c.Name)
+		}
+	}
+	for i, p := range h.Proceedings {
+		for j, a := range p.Appointees {
+			check(fmt.Sprintf("倒産手続[%d].%s[%d]", i, a.Role, j), a.Name)
+		}
+	}
+	return nil
+}
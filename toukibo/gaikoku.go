@@ -0,0 +1,100 @@
+package toukibo
+
+import (
+	"regexp"
+	"strings"
+)
+
+const japanRepresentativeTitle = "日本における代表者"
+
+// ForeignCompany は外国会社に特有の登記事項
+type ForeignCompany struct {
+	LatinName            string   // 商号のうちラテン文字で書かれた部分
+	HomeHeadOffice       string   // 本国における本店
+	JapanOffices         []string // 日本における営業所
+	JapanRepresentatives []Officer
+	GoverningLaw         string // 準拠法
+	HomeKoukoku          string // 外国会社の公告方法
+}
+
+// 外国会社の登記にだけ現れる項目名
+var gaikokuLabels = []string{"本国における本店", "日本における営業所", "日本における代表者", "準拠法"}
+
+var latinNameRegex = regexp.MustCompile(`[Ａ-Ｚａ-ｚA-Za-z][Ａ-Ｚａ-ｚA-Za-z０-９0-9　 ．.，,＆&’'－\-]*`)
+
+// CheckGaikokuKaisha は外国会社の項目があれば法人格を外国会社とする
+func (h *Houjin) CheckGaikokuKaisha() bool {
+	for _, part := range h.Parts {
+		if containsAny(newSection(part).label, gaikokuLabels) {
+			h.HoujinType = HoujinKakuGaikoku
+			return true
+		}
+	}
+	return false
+}
+
+func latinName(s string) string {
+	longest := ""
+	for _, m := range latinNameRegex.FindAllString(s, -1) {
+		if len(m) > len(longest) {
+			longest = m
+		}
+	}
+	return strings.Trim(ZenkakuToHankaku(longest), " ,-")
+}
+
+// sectionText は区画の最後の登記事項から日付を取り除いた内容を返す
+func sectionText(s section) string {
+	entries := s.entries()
+	if len(entries) == 0 {
+		return ""
+	}
+	body, _ := splitRegisteredDate(joinLines(entries[len(entries)-1]))
+	body, _, _ = splitEventDate(body)
+	return strings.Trim(body, "　 ")
+}
+
+func (h *Houjin) ReadForeignCompany() error {
+	if h.HoujinType != HoujinKakuGaikoku {
+		return nil
+	}
+	f := &ForeignCompany{}
+	for _, part := range h.Parts {
+		s := newSection(part)
+		switch {
+		case s.label == "商号":
+			f.LatinName = latinName(joinLines(s.lines))
+		case strings.Contains(s.label, "本国における本店"):
+			f.HomeHeadOffice = ZenkakuToHankaku(sectionText(s))
+		case strings.Contains(s.label, "日本における営業所"):
+			for _, entry := range s.entries() {
+				body, _ := splitRegisteredDate(joinLines(entry))
+				body, _, event := splitEventDate(body)
+				if event == "廃止" {
+					continue
+				}
+				f.JapanOffices = append(f.JapanOffices, ZenkakuToHankaku(strings.Trim(body, "　 ")))
+			}
+		case strings.Contains(s.label, japanRepresentativeTitle):
+			for _, entry := range s.entries() {
+				if o, ok := parseOfficer(entry); ok {
+					if o.Title == "" {
+						o.Title = japanRepresentativeTitle
+					}
+					f.JapanRepresentatives = append(f.JapanRepresentatives, o)
+				}
+			}
+		case strings.Contains(s.label, "準拠法"):
+			f.GoverningLaw = sectionText(s)
+		case strings.Contains(s.label, "外国会社の公告"):
+			f.HomeKoukoku = sectionText(s)
+		}
+	}
+	for _, o := range h.Officers {
+		if strings.HasPrefix(o.Title, japanRepresentativeTitle) {
+			f.JapanRepresentatives = append(f.JapanRepresentatives, o)
+		}
+	}
+	h.Foreign = f
+	return nil
+}
@@ -5,6 +5,9 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"vandal/jusho"
+	"vandal/normalize"
 )
 
 type HoujinkakuType string
@@ -36,6 +39,7 @@ const (
 	HoujinKakuIppanZaidan     HoujinkakuType = "一般財団法人"
 	HoujinKakuNPO             HoujinkakuType = "NPO法人"
 	HoujinKakuTokuteiHieiri   HoujinkakuType = "特定非営利活動法人"
+	HoujinKakuGaikoku         HoujinkakuType = "外国会社"
 )
 
 func FindHoujinKaku(s string) HoujinkakuType {
@@ -91,18 +95,78 @@ func FindHoujinKaku(s string) HoujinkakuType {
 	return HoujinKakuUnknown
 }
 
+var houjinKakuList = []HoujinkakuType{
+	HoujinKakuKabusiki,
+	HoujinKakuYugen,
+	HoujinKakuGoudou,
+	HoujinKakuGousi,
+	HoujinKakuGoumei,
+	HoujinKakuTokuteiMokuteki,
+	HoujinKakuKyodou,
+	HoujinKakuRoudou,
+	HoujinKakuSinrin,
+	HoujinKakuSeikatuEisei,
+	HoujinKakuSinyou,
+	HoujinKakuShokoukai,
+	HoujinKakuKoueki,
+	HoujinKakuNouji,
+	HoujinKakuShukyo,
+	HoujinKakuKanriKumiai,
+	HoujinKakuIryo,
+	HoujinKakuSihoshosi,
+	HoujinKakuZeirishi,
+	HoujinKakuShakaifukusi,
+	HoujinKakuIppanShadan,
+	HoujinKakuIppanZaisan,
+	HoujinKakuIppanZaidan,
+	HoujinKakuNPO,
+	HoujinKakuTokuteiHieiri,
+}
+
+// indexHoujinKaku は文字列中で最初に現れる法人格の位置を返す。見つからなければ-1を返す
+func indexHoujinKaku(s string) int {
+	index := -1
+	for _, t := range houjinKakuList {
+		if i := strings.Index(s, string(t)); i >= 0 && (index < 0 || i < index) {
+			index = i
+		}
+	}
+	return index
+}
+
 type Houjin struct {
 	Content            string
 	Parts              []string
 	CreatedAt          time.Time
+	Kankatsu           string
+	Toukikan           string
+	ShoumeiDate        string
 	HoujinNumber       string
 	HoujinType         HoujinkakuType
 	CompanyName        string
 	CompanyAddress     string
 	Sihonkin           string
 	Koukoku            string
+	KoukokuMethod      KoukokuMethod
+	KoukokuHistory     []KoukokuMethod
+	BalanceSheetURLs   []BalanceSheetURL
 	CompanyCreatedDate string
 	ToukiJiko          string
+	ToukiKiroku        []ToukiKiroku
+	Officers           []Officer
+	MainOffice         string
+	BranchOffices      []string
+	Purpose            string
+	TotalAssets        []Amount
+	KihonZaisan        []Amount
+	KyokaDate          string
+	Foreign            *ForeignCompany
+	Reorganisations    []Reorganisation
+	Proceedings        []Proceeding
+	Status             HoujinStatus
+	Gaiji              []Gaiji
+	IssuingOffice      *RegistryOffice
+	Warnings           []string
 }
 
 func NewHoujinFromToukibo(tc ToukiboContent) *Houjin {
@@ -112,18 +176,63 @@ func NewHoujinFromToukibo(tc ToukiboContent) *Houjin {
 		CreatedAt:      tc.Header.CreatedAt,
 		CompanyName:    tc.Header.CompanyName,
 		CompanyAddress: tc.Header.CompanyAddress,
+		Kankatsu:       tc.Header.Kankatsu,
+		Toukikan:       tc.Header.Toukikan,
+		ShoumeiDate:    tc.Header.ShoumeiDate,
 	}
 }
 
+// Jusho は本店の住所を分割して返す
+func (h *Houjin) Jusho() jusho.Address {
+	return jusho.Parse(h.CompanyAddress)
+}
+
+// NameMatches は name が商号と異体字や全角・半角の違いを除いて同じであればtrueを返す
+func (h *Houjin) NameMatches(name string) bool {
+	return normalize.SameName(h.CompanyName, name)
+}
+
 func (h *Houjin) String() string {
-	return fmt.Sprintf("法人番号: %s\n法人格: %s\n商号: %s\n住所: %s\n資本金: %s\n設立日: %s\n登記事項: %s\n",
+	// 「法人番号:」は以前から会社法人等番号を表しているので、そのまま残す。
+	// 国税庁の13桁の法人番号は別の見出しで出す
+	s := fmt.Sprintf("法人番号: %s\n法人番号（13桁）: %s\n法人格: %s\n商号: %s\n住所: %s\n資本金: %s\n設立日: %s\n登記事項: %s\n",
 		h.HoujinNumber,
+		h.CorporateNumber(),
 		h.HoujinType,
 		h.CompanyName,
 		h.CompanyAddress,
 		h.Sihonkin,
 		h.CompanyCreatedDate,
 		h.ToukiJiko)
+	s += fmt.Sprintf("公告方法: %s\n", h.KoukokuMethod)
+	if url := h.CurrentBalanceSheetURL(); url != "" {
+		s += fmt.Sprintf("貸借対照表: %s\n", url)
+	}
+	for _, o := range h.CurrentOfficers() {
+		s += fmt.Sprintf("役員: %s\n", o)
+	}
+	for _, k := range h.ToukiKiroku {
+		s += fmt.Sprintf("登記記録: %s\n", k)
+	}
+	s += fmt.Sprintf("状態: %s\n", h.Status)
+	for _, r := range h.Reorganisations {
+		s += fmt.Sprintf("組織再編: %s\n", r)
+	}
+	for _, p := range h.Proceedings {
+		s += fmt.Sprintf("倒産手続: %s\n", p)
+	}
+	for _, g := range h.Gaiji {
+		s += fmt.Sprintf("外字: %s\n", g)
+	}
+	if h.IssuingOffice != nil {
+		s += fmt.Sprintf("登記所: %s\n", h.IssuingOffice)
+	} else if code := h.officeCode(); code != "" {
+		s += fmt.Sprintf("登記所: 不明（登記所コード%s）\n", code)
+	}
+	for _, w := range h.Warnings {
+		s += fmt.Sprintf("警告: %s\n", w)
+	}
+	return s
 }
 
 func (h *Houjin) ReadHoujinNumber() error {
@@ -133,13 +242,43 @@ func (h *Houjin) ReadHoujinNumber() error {
 
 	matches := regex.FindStringSubmatch(h.Parts[0])
 	if len(matches) > 0 {
-		h.HoujinNumber = zenkakuToHankaku(matches[1])
+		h.HoujinNumber = ZenkakuToHankaku(matches[1])
 	} else {
 		return fmt.Errorf("法人番号が見つかりませんでした")
 	}
 	return nil
 }
 
+// CheckDigit は12桁の会社法人等番号から法人番号の先頭に付ける検査用数字を計算する
+func CheckDigit(base string) (int, error) {
+	if len(base) != 12 {
+		return 0, fmt.Errorf("会社法人等番号は12桁です: %s", base)
+	}
+	sum := 0
+	for n := 1; n <= 12; n++ {
+		c := base[12-n]
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("会社法人等番号に数字以外が含まれています: %s", base)
+		}
+		q := 1
+		if n%2 == 0 {
+			q = 2
+		}
+		sum += int(c-'0') * q
+	}
+	return 9 - sum%9, nil
+}
+
+// CorporateNumber は会社法人等番号から13桁の法人番号を返す。計算できなければ空を返す
+func (h *Houjin) CorporateNumber() string {
+	base := strings.ReplaceAll(h.HoujinNumber, "-", "")
+	d, err := CheckDigit(base)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d%s", d, base)
+}
+
 func contains(target HoujinkakuType, list []HoujinkakuType) bool {
 	for _, t := range list {
 		if t == target {
@@ -178,15 +317,25 @@ func (h *Houjin) ReadCompanyCreatedDate() error {
 
 	matches := regex.FindStringSubmatch(h.Content)
 	if len(matches) > 0 {
-		h.CompanyCreatedDate = zenkakuToHankaku(strings.TrimSpace(matches[2]))
-	} else {
+		h.CompanyCreatedDate = ZenkakuToHankaku(strings.TrimSpace(matches[2]))
+	} else if h.HoujinType != HoujinKakuGaikoku {
 		return fmt.Errorf("法人成立の年月日が見つかりませんでした。")
 	}
 	return nil
 }
 
 func (h *Houjin) CheckShukyoHoujin() bool {
-	if h.HoujinType == HoujinKakuUnknown && strings.Contains(h.Content, "宗教法人") {
+	if h.HoujinType != HoujinKakuUnknown {
+		return false
+	}
+	// 宗教法人の名称には法人格が含まれないことが多いので、役員の役職名から判断する
+	for _, s := range findSections(h.Parts, "役員") {
+		if containsAny(joinLines(s.lines), []string{"代表役員", "責任役員"}) {
+			h.HoujinType = HoujinKakuShukyo
+			return true
+		}
+	}
+	if strings.Contains(h.Content, "宗教法人") {
 		h.HoujinType = HoujinKakuShukyo
 		return true
 	}
@@ -211,7 +360,7 @@ func (h *Houjin) ReadToukiJikou() error {
 
 	// 末尾の記号を削除
 	cleanedText = strings.Replace(cleanedText, "┃", "", -1)
-	h.ToukiJiko = zenkakuToHankaku(cleanedText)
+	h.ToukiJiko = ZenkakuToHankaku(cleanedText)
 	return nil
 }
 
@@ -230,7 +379,7 @@ func (h *Houjin) ReadSihonkin() error {
 
 	matches := regex.FindStringSubmatch(h.Content)
 	if len(matches) > 0 {
-		h.Sihonkin = zenkakuToHankaku(strings.TrimSpace(matches[1]))
+		h.Sihonkin = ZenkakuToHankaku(strings.TrimSpace(matches[1]))
 	} else {
 		return fmt.Errorf("資本金が見つかりませんでした。")
 	}
@@ -244,7 +393,8 @@ func (h *Houjin) Extract() error {
 	}
 
 	h.HoujinType = FindHoujinKaku(h.CompanyName)
-	if h.HoujinType == HoujinKakuUnknown {
+	// 外国会社の商号には「株式会社」などが含まれることがあるので項目名を優先する
+	if !h.CheckGaikokuKaisha() && h.HoujinType == HoujinKakuUnknown {
 		check := h.CheckShukyoHoujin()
 		if !check {
 			return fmt.Errorf("法人格が不明です")
@@ -256,6 +406,31 @@ func (h *Houjin) Extract() error {
 		panic(err)
 	}
 
+	err = h.ReadOfficers()
+	if err != nil {
+		panic(err)
+	}
+
+	err = h.ReadProfile()
+	if err != nil {
+		panic(err)
+	}
+
+	err = h.ReadForeignCompany()
+	if err != nil {
+		panic(err)
+	}
+
+	err = h.ReadKoukokuMethod()
+	if err != nil {
+		panic(err)
+	}
+
+	err = h.ReadBalanceSheetURLs()
+	if err != nil {
+		panic(err)
+	}
+
 	err = h.ReadCompanyCreatedDate()
 	if err != nil {
 		panic(err)
@@ -266,10 +441,37 @@ func (h *Houjin) Extract() error {
 		panic(err)
 	}
 
+	err = h.ReadToukiKiroku()
+	if err != nil {
+		panic(err)
+	}
+
 	err = h.ReadSihonkin()
 	if err != nil {
 		panic(err)
 	}
 
+	err = h.ReadReorganisations()
+	if err != nil {
+		panic(err)
+	}
+
+	err = h.ReadProceedings()
+	if err != nil {
+		panic(err)
+	}
+
+	err = h.CheckGaiji()
+	if err != nil {
+		panic(err)
+	}
+
+	err = h.ReadIssuingOffice()
+	if err != nil {
+		panic(err)
+	}
+
+	h.ComputeStatus()
+
 	return nil
 }
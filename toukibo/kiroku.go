@@ -0,0 +1,157 @@
+package toukibo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type ToukiKirokuKind string
+
+const (
+	ToukiKirokuSetsuritsu     ToukiKirokuKind = "設立"
+	ToukiKirokuHontenIntenIn  ToukiKirokuKind = "他の登記所からの本店移転"
+	ToukiKirokuHontenIntenOut ToukiKirokuKind = "他の登記所への本店移転"
+	ToukiKirokuSoshikiHenko   ToukiKirokuKind = "組織変更"
+	ToukiKirokuIkou           ToukiKirokuKind = "移行"
+	ToukiKirokuGappei         ToukiKirokuKind = "合併"
+	ToukiKirokuBunkatsu       ToukiKirokuKind = "分割"
+	ToukiKirokuIki            ToukiKirokuKind = "登記簿の移記"
+	ToukiKirokuSeisanKetsuryo ToukiKirokuKind = "清算結了"
+	ToukiKirokuFukkatsu       ToukiKirokuKind = "閉鎖登記記録の復活"
+	ToukiKirokuHeisa          ToukiKirokuKind = "閉鎖"
+	ToukiKirokuOther          ToukiKirokuKind = "その他"
+)
+
+// ToukiKiroku は登記記録に関する事項の1回分の登記で、登記記録がどのように
+// 起こされ、または閉じられたかを表す
+type ToukiKiroku struct {
+	Kind           ToukiKirokuKind
+	Date           string // 事由の発生日
+	RegisteredDate string
+	ClosedDate     string // 登記記録が閉鎖された日。閉鎖されていなければ空
+	Address        string // 本店移転の場合の移転前または移転後の本店
+	Office         string // 記載された登記所
+	Raw            string
+}
+
+// 長い語句から順に照合する
+var toukiKirokuRules = []struct {
+	phrase string
+	kind   ToukiKirokuKind
+}{
+	{"移記", ToukiKirokuIki},
+	{"に本店移転", ToukiKirokuHontenIntenOut},
+	{"から本店移転", ToukiKirokuHontenIntenIn},
+	{"組織変更", ToukiKirokuSoshikiHenko},
+	{"移行", ToukiKirokuIkou},
+	{"合併", ToukiKirokuGappei},
+	{"分割", ToukiKirokuBunkatsu},
+	{"清算結了", ToukiKirokuSeisanKetsuryo},
+	{"復活", ToukiKirokuFukkatsu},
+	{"設立", ToukiKirokuSetsuritsu},
+}
+
+var (
+	closedDateRegex = regexp.MustCompile(`(` + warekiPattern + `)閉鎖`)
+	officeRegex     = regexp.MustCompile(`\p{Han}+?(?:地方)?法務局(?:\p{Han}+?(?:支局|出張所))?`)
+)
+
+func (k ToukiKiroku) String() string {
+	s := fmt.Sprintf("%s %s", k.Date, k.Kind)
+	if k.Office != "" {
+		s += "（" + k.Office + "）"
+	}
+	if k.ClosedDate != "" {
+		s += fmt.Sprintf(" %s閉鎖", k.ClosedDate)
+	}
+	return s
+}
+
+// Closed は登記記録が閉鎖されていればtrueを返す
+func (k ToukiKiroku) Closed() bool {
+	return k.ClosedDate != ""
+}
+
+func parseToukiKiroku(text string) ToukiKiroku {
+	k := ToukiKiroku{Kind: ToukiKirokuOther, Raw: text}
+
+	body := text
+	if loc := closedDateRegex.FindStringSubmatchIndex(body); loc != nil {
+		k.ClosedDate = NormalizeDate(body[loc[2]:loc[3]])
+		body = body[:loc[0]] + body[loc[1]:]
+	}
+	body, k.RegisteredDate = splitRegisteredDate(body)
+	body, k.Date = splitLeadingDate(body)
+
+	for _, rule := range toukiKirokuRules {
+		i := strings.Index(body, rule.phrase)
+		if i < 0 {
+			continue
+		}
+		k.Kind = rule.kind
+		if rule.kind == ToukiKirokuHontenIntenIn || rule.kind == ToukiKirokuHontenIntenOut {
+			k.Address = ZenkakuToHankaku(strings.Trim(body[:i], "　 "))
+		}
+		break
+	}
+	if k.Kind == ToukiKirokuOther && k.ClosedDate != "" {
+		k.Kind = ToukiKirokuHeisa
+	}
+	if k.Kind == ToukiKirokuIki {
+		// 移記の日は「…移記」の直前に書かれる
+		if loc := WarekiRegex.FindAllStringIndex(body, -1); len(loc) > 0 {
+			last := loc[len(loc)-1]
+			k.Date = NormalizeDate(body[last[0]:last[1]])
+		}
+	}
+	if k.Date == "" {
+		k.Date = k.RegisteredDate
+	}
+	k.Office = officeRegex.FindString(body)
+	return k
+}
+
+func (h *Houjin) ReadToukiKiroku() error {
+	h.ToukiKiroku = nil
+	for _, s := range findSections(h.Parts, "登記記録に関する") {
+		for _, entry := range s.entries() {
+			h.ToukiKiroku = append(h.ToukiKiroku, parseToukiKiroku(joinLines(entry)))
+		}
+	}
+	return nil
+}
+
+// Origin は登記記録が起こされた事由を返す。見つからなければnilを返す
+func (h *Houjin) Origin() *ToukiKiroku {
+	for i := range h.ToukiKiroku {
+		if !h.ToukiKiroku[i].Closed() {
+			return &h.ToukiKiroku[i]
+		}
+	}
+	return nil
+}
+
+// Closure は登記記録が閉鎖された事由を返す。閉鎖されていなければnilを返す
+func (h *Houjin) Closure() *ToukiKiroku {
+	for i := len(h.ToukiKiroku) - 1; i >= 0; i-- {
+		if h.ToukiKiroku[i].Closed() {
+			return &h.ToukiKiroku[i]
+		}
+	}
+	return nil
+}
+
+// HistoryIncomplete は登記記録が移記や他の登記所からの本店移転などで起こされており、
+// それより前の履歴がこの証明書に載っていなければtrueを返す
+func (h *Houjin) HistoryIncomplete() bool {
+	origin := h.Origin()
+	if origin == nil {
+		return false
+	}
+	switch origin.Kind {
+	case ToukiKirokuHontenIntenIn, ToukiKirokuIki, ToukiKirokuSoshikiHenko, ToukiKirokuIkou, ToukiKirokuFukkatsu:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package toukibo
+
+import (
+	"regexp"
+	"strings"
+)
+
+type KoukokuType string
+
+const (
+	KoukokuUnknown   KoukokuType = "不明"
+	KoukokuKanpou    KoukokuType = "官報"
+	KoukokuNewspaper KoukokuType = "日刊新聞紙"
+	KoukokuDenshi    KoukokuType = "電子公告"
+	KoukokuKeiji     KoukokuType = "掲示"
+)
+
+// KoukokuMethod は公告をする方法の1回分の登記
+type KoukokuMethod struct {
+	Type      KoukokuType
+	Newspaper string // Type が日刊新聞紙の場合の新聞名
+	URL       string // Type が電子公告の場合の公告掲載URL
+	// 電子公告ができない場合の予備的な公告方法
+	FallbackType      KoukokuType
+	FallbackNewspaper string
+	ChangedDate       string
+	RegisteredDate    string
+	Text              string
+}
+
+// BalanceSheetURL は貸借対照表に係る情報の提供を受けるために必要な事項の1回分の登記
+type BalanceSheetURL struct {
+	URL            string
+	ChangedDate    string
+	RegisteredDate string
+}
+
+var (
+	urlRegex       = regexp.MustCompile(`https?://[!-~]+`)
+	newspaperRegex = regexp.MustCompile(`([^、。　 ]+?新聞)に掲載`)
+)
+
+func (k KoukokuMethod) String() string {
+	s := string(k.Type)
+	switch k.Type {
+	case KoukokuNewspaper:
+		s += "（" + k.Newspaper + "）"
+	case KoukokuDenshi:
+		s += "（" + k.URL + "）"
+	}
+	if k.FallbackType != "" {
+		s += " 予備: " + string(k.FallbackType)
+		if k.FallbackNewspaper != "" {
+			s += "（" + k.FallbackNewspaper + "）"
+		}
+	}
+	return s
+}
+
+// findURL は全角で書かれたURLも半角にして取り出す
+func findURL(s string) string {
+	return strings.TrimRight(urlRegex.FindString(ZenkakuToHankaku(s)), ".,)")
+}
+
+func readNewspaper(s string) string {
+	if i := strings.LastIndex(s, "発行する"); i >= 0 {
+		s = s[i+len("発行する"):]
+	}
+	m := newspaperRegex.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+func readKoukokuType(s string) (KoukokuType, string) {
+	switch {
+	case strings.Contains(s, "電子公告"):
+		return KoukokuDenshi, ""
+	case strings.Contains(s, "新聞"):
+		return KoukokuNewspaper, readNewspaper(s)
+	case strings.Contains(s, "官報"):
+		return KoukokuKanpou, ""
+	case strings.Contains(s, "掲示"):
+		return KoukokuKeiji, ""
+	}
+	return KoukokuUnknown, ""
+}
+
+func parseKoukokuMethod(text string) KoukokuMethod {
+	body, registeredDate := splitRegisteredDate(text)
+	body, changedDate, _ := splitEventDate(body)
+
+	k := KoukokuMethod{
+		ChangedDate:    changedDate,
+		RegisteredDate: registeredDate,
+		Text:           body,
+	}
+
+	main, fallback := body, ""
+	if i := strings.Index(body, "やむを得ない"); i >= 0 {
+		main, fallback = body[:i], body[i:]
+	}
+	k.Type, k.Newspaper = readKoukokuType(main)
+	if k.Type == KoukokuDenshi {
+		k.URL = findURL(body)
+		if fallback != "" {
+			k.FallbackType, k.FallbackNewspaper = readKoukokuType(strings.Replace(fallback, "電子公告", "", -1))
+		}
+	}
+	return k
+}
+
+func (h *Houjin) ReadKoukokuMethod() error {
+	h.KoukokuHistory = nil
+	for _, s := range findSections(h.Parts, "公告") {
+		for _, entry := range s.entries() {
+			h.KoukokuHistory = append(h.KoukokuHistory, parseKoukokuMethod(joinLines(entry)))
+		}
+	}
+	if len(h.KoukokuHistory) > 0 {
+		h.KoukokuMethod = h.KoukokuHistory[len(h.KoukokuHistory)-1]
+	}
+	return nil
+}
+
+func (h *Houjin) ReadBalanceSheetURLs() error {
+	h.BalanceSheetURLs = nil
+	for _, s := range findSections(h.Parts, "貸借対照表") {
+		for _, entry := range s.entries() {
+			body, registeredDate := splitRegisteredDate(joinLines(entry))
+			body, changedDate, _ := splitEventDate(body)
+			url := findURL(body)
+			if url == "" {
+				continue
+			}
+			h.BalanceSheetURLs = append(h.BalanceSheetURLs, BalanceSheetURL{
+				URL:            url,
+				ChangedDate:    changedDate,
+				RegisteredDate: registeredDate,
+			})
+		}
+	}
+	return nil
+}
+
+// CurrentBalanceSheetURL は現在の貸借対照表の公開先URLを返す。登記がなければ空文字を返す
+func (h *Houjin) CurrentBalanceSheetURL() string {
+	if len(h.BalanceSheetURLs) == 0 {
+		return ""
+	}
+	return h.BalanceSheetURLs[len(h.BalanceSheetURLs)-1].URL
+}
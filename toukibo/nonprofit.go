@@ -0,0 +1,114 @@
+package toukibo
+
+import (
+	"strings"
+)
+
+// Amount は資産の総額などの金額の1回分の登記
+type Amount struct {
+	Value          string
+	ChangedDate    string
+	RegisteredDate string
+}
+
+// 法人格ごとに追加で読み取る項目
+var houjinKakuProfiles = map[HoujinkakuType][]func(*Houjin) error{
+	HoujinKakuIppanShadan:   {(*Houjin).ReadOffices, (*Houjin).ReadPurpose},
+	HoujinKakuIppanZaidan:   {(*Houjin).ReadOffices, (*Houjin).ReadPurpose, (*Houjin).ReadKihonZaisan},
+	HoujinKakuIppanZaisan:   {(*Houjin).ReadOffices, (*Houjin).ReadPurpose, (*Houjin).ReadKihonZaisan},
+	HoujinKakuKoueki:        {(*Houjin).ReadOffices, (*Houjin).ReadPurpose, (*Houjin).ReadKihonZaisan},
+	HoujinKakuShakaifukusi:  {(*Houjin).ReadOffices, (*Houjin).ReadPurpose, (*Houjin).ReadTotalAssets, (*Houjin).ReadKyokaDate},
+	HoujinKakuIryo:          {(*Houjin).ReadOffices, (*Houjin).ReadPurpose, (*Houjin).ReadTotalAssets, (*Houjin).ReadKyokaDate},
+	HoujinKakuShukyo:        {(*Houjin).ReadOffices, (*Houjin).ReadPurpose, (*Houjin).ReadTotalAssets, (*Houjin).ReadKyokaDate},
+	HoujinKakuNPO:           {(*Houjin).ReadOffices, (*Houjin).ReadPurpose, (*Houjin).ReadTotalAssets},
+	HoujinKakuTokuteiHieiri: {(*Houjin).ReadOffices, (*Houjin).ReadPurpose, (*Houjin).ReadTotalAssets},
+}
+
+// ReadProfile は法人格に応じた項目を読み取る
+func (h *Houjin) ReadProfile() error {
+	for _, read := range houjinKakuProfiles[h.HoujinType] {
+		if err := read(h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readAmounts(parts []string, label string) []Amount {
+	var amounts []Amount
+	for _, s := range findSections(parts, label) {
+		for _, entry := range s.entries() {
+			body, registeredDate := splitRegisteredDate(joinLines(entry))
+			body, changedDate, _ := splitEventDate(body)
+			amounts = append(amounts, Amount{
+				Value:          ZenkakuToHankaku(strings.Trim(body, "　 ")),
+				ChangedDate:    changedDate,
+				RegisteredDate: registeredDate,
+			})
+		}
+	}
+	return amounts
+}
+
+// ReadTotalAssets は資産の総額を読み取る
+func (h *Houjin) ReadTotalAssets() error {
+	h.TotalAssets = readAmounts(h.Parts, "資産の総額")
+	return nil
+}
+
+// ReadKihonZaisan は基本財産の総額を読み取る
+func (h *Houjin) ReadKihonZaisan() error {
+	h.KihonZaisan = readAmounts(h.Parts, "基本財産")
+	return nil
+}
+
+// ReadKyokaDate は設立の許可（認可、認証）の年月日を読み取る
+func (h *Houjin) ReadKyokaDate() error {
+	for _, s := range h.Parts {
+		sec := newSection(s)
+		if !strings.Contains(sec.label, "設立") || !containsAny(sec.label, []string{"許可", "認可", "認証"}) {
+			continue
+		}
+		if d := WarekiRegex.FindString(joinLines(sec.lines)); d != "" {
+			h.KyokaDate = NormalizeDate(d)
+		}
+	}
+	return nil
+}
+
+// ReadOffices は主たる事務所と従たる事務所を読み取る。会社の場合は本店と支店を読み取る
+func (h *Houjin) ReadOffices() error {
+	h.MainOffice = ""
+	h.BranchOffices = nil
+	for _, part := range h.Parts {
+		s := newSection(part)
+		switch {
+		case strings.Contains(s.label, "主たる事務所") || s.label == "本店":
+			for _, entry := range s.entries() {
+				body, _ := splitRegisteredDate(joinLines(entry))
+				body, _, _ = splitEventDate(body)
+				h.MainOffice = ZenkakuToHankaku(strings.Trim(body, "　 "))
+			}
+		case strings.Contains(s.label, "従たる事務所") || s.label == "支店":
+			for _, entry := range s.entries() {
+				body, _ := splitRegisteredDate(joinLines(entry))
+				body, _, event := splitEventDate(body)
+				if event == "廃止" {
+					continue
+				}
+				// 「１」「２」などの番号を取り除く
+				body = strings.TrimLeft(strings.Trim(body, "　 "), "０１２３４５６７８９0123456789　 ")
+				h.BranchOffices = append(h.BranchOffices, ZenkakuToHankaku(body))
+			}
+		}
+	}
+	return nil
+}
+
+// ReadPurpose は目的（目的等、目的及び事業）を読み取る
+func (h *Houjin) ReadPurpose() error {
+	for _, s := range findSections(h.Parts, "目的") {
+		h.Purpose = strings.Trim(joinLines(s.lines), "　 ")
+	}
+	return nil
+}
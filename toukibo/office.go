@@ -0,0 +1,170 @@
+package toukibo
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RegistryOffice は会社法人等番号を付けた登記所
+type RegistryOffice struct {
+	Code          string // 会社法人等番号の上4桁
+	Name          string
+	Kind          string // 法務局、地方法務局、支局、出張所
+	Prefecture    string
+	AbolishedDate string // 廃止日または名称変更日。現存していれば空
+	MergedInto    string // 統合先の登記所コード。名称変更だけであれば空
+}
+
+func (o RegistryOffice) String() string {
+	return o.Name
+}
+
+// OfficeTable は登記所コード表
+type OfficeTable struct {
+	Version string
+	offices map[string][]RegistryOffice
+}
+
+//go:embed offices.csv
+var officesCSV string
+
+var officeTable = mustLoadOfficeTable(officesCSV)
+
+func mustLoadOfficeTable(s string) *OfficeTable {
+	t, err := LoadOfficeTable(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// LoadOfficeTable は埋め込みの表と同じ形式の登記所コード表を読み込む
+func LoadOfficeTable(r io.Reader) (*OfficeTable, error) {
+	t := &OfficeTable{offices: make(map[string][]RegistryOffice)}
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if v, ok := strings.CutPrefix(text, "# version:"); ok {
+			t.Version = strings.TrimSpace(v)
+			continue
+		}
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		f := strings.Split(text, ",")
+		if len(f) != 6 || len(f[0]) != 4 {
+			return nil, fmt.Errorf("登記所コード表の%d行目が読み取れませんでした", line)
+		}
+		o := RegistryOffice{Code: f[0], Name: f[1], Kind: f[2], Prefecture: f[3], AbolishedDate: f[4], MergedInto: f[5]}
+		t.offices[o.Code] = append(t.offices[o.Code], o)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// SetOfficeTable は照合に使う登記所コード表を差し替える。nilを渡すと埋め込みの表に戻す
+func SetOfficeTable(t *OfficeTable) {
+	if t == nil {
+		t = mustLoadOfficeTable(officesCSV)
+	}
+	officeTable = t
+}
+
+// Lookup は登記所コードに対応する登記所を返す。現存する登記所を優先する。
+// 表にないコードは、支局や出張所であっても本局で代えずに false を返す
+func (t *OfficeTable) Lookup(code string) (RegistryOffice, bool) {
+	if len(code) != 4 {
+		return RegistryOffice{}, false
+	}
+	return t.lookup(code)
+}
+
+// District は登記所コードの上2桁から、その登記所を管轄する法務局または地方法務局を返す。
+// 支局や出張所は本局と同じ都道府県にあるので、都道府県の照合に使える
+func (t *OfficeTable) District(code string) (RegistryOffice, bool) {
+	if len(code) != 4 {
+		return RegistryOffice{}, false
+	}
+	return t.lookup(code[:2] + "00")
+}
+
+func (t *OfficeTable) lookup(code string) (RegistryOffice, bool) {
+	offices := t.offices[code]
+	for _, o := range offices {
+		if o.AbolishedDate == "" {
+			return o, true
+		}
+	}
+	if len(offices) > 0 {
+		return offices[len(offices)-1], true
+	}
+	return RegistryOffice{}, false
+}
+
+// History は登記所コードに対応する廃止された登記所と旧名称を返す
+func (t *OfficeTable) History(code string) []RegistryOffice {
+	var history []RegistryOffice
+	for _, o := range t.offices[code] {
+		if o.AbolishedDate != "" {
+			history = append(history, o)
+		}
+	}
+	return history
+}
+
+// ReadIssuingOffice は会社法人等番号から登記所を読み取り、本店の都道府県と食い違っていて
+// 本店移転の登記もなければ Warnings に記録する。表にない登記所は不明のままにし、
+// 都道府県はコードの上2桁の法務局または地方法務局で照合する。
+// 埋め込みの表には支局と出張所が載っていないので、表にないことは警告しない
+func (h *Houjin) ReadIssuingOffice() error {
+	h.IssuingOffice = nil
+	code := h.officeCode()
+	o, ok := officeTable.Lookup(code)
+	if ok {
+		h.IssuingOffice = &o
+	} else if o, ok = officeTable.District(code); !ok {
+		return nil
+	}
+
+	pref := h.Jusho().Prefecture
+	if pref == "" || pref == o.Prefecture || h.hasHontenIten() {
+		return nil
+	}
+	name := o.Name
+	if h.IssuingOffice == nil {
+		name = fmt.Sprintf("登記所コード%s、%sの管轄", code, o.Name)
+	}
+	h.Warnings = append(h.Warnings, fmt.Sprintf("会社法人等番号の登記所（%s、%s）と本店の都道府県（%s）が異なりますが、本店移転の登記がありません", name, o.Prefecture, pref))
+	return nil
+}
+
+// officeCode は会社法人等番号の上4桁の登記所コードを返す
+func (h *Houjin) officeCode() string {
+	code, _, _ := strings.Cut(h.HoujinNumber, "-")
+	return code
+}
+
+func (h *Houjin) hasHontenIten() bool {
+	for _, k := range h.ToukiKiroku {
+		if k.Kind == ToukiKirokuHontenIntenIn {
+			return true
+		}
+	}
+	for _, part := range h.Parts {
+		s := newSection(part)
+		if s.label != "本店" && !strings.Contains(s.label, "主たる事務所") {
+			continue
+		}
+		for _, entry := range s.entries() {
+			if _, _, event := splitEventDate(joinLines(entry)); event == "移転" {
+				return true
+			}
+		}
+	}
+	return false
+}
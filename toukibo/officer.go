@@ -0,0 +1,151 @@
+package toukibo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"vandal/jusho"
+	"vandal/normalize"
+)
+
+// Officer は役員に関する事項の1回分の登記
+type Officer struct {
+	Title          string
+	Name           string
+	Address        string
+	Event          string // 就任、重任、辞任など
+	Date           string
+	RegisteredDate string
+}
+
+var (
+	fieldSeparatorRegex = regexp.MustCompile(`[　 ]{2,}`)
+	addressStartRegex   = regexp.MustCompile(`^` + prefecturePattern)
+)
+
+// 項目名の区切りが1文字分しかない場合に備えて、既知の役職名で分ける。長いものから順に照合する
+var officerTitles = []string{
+	"代表取締役", "代表執行役", "代表清算人", "代表理事", "代表役員", "代表社員", "代表者",
+	"業務執行社員", "無限責任社員", "有限責任社員", "会計監査人", "会計参与", "責任役員",
+	"取締役", "監査役", "執行役", "清算人", "理事長", "副理事長", "常務理事", "理事", "監事",
+	"評議員", "社員", "会長",
+}
+
+func (o Officer) String() string {
+	return fmt.Sprintf("%s %s（%s %s）", o.Title, o.Name, o.Date, o.Event)
+}
+
+// Jusho は住所を分割して返す
+func (o Officer) Jusho() jusho.Address {
+	return jusho.Parse(o.Address)
+}
+
+// Representative は代表権を持つ役職であればtrueを返す
+func (o Officer) Representative() bool {
+	return strings.HasPrefix(o.Title, "代表") || o.Title == "理事長"
+}
+
+func splitTitleName(s string) (string, string) {
+	fields := fieldSeparatorRegex.Split(strings.Trim(s, "　 "), 2)
+	if len(fields) == 2 {
+		return fields[0], strings.Trim(fields[1], "　 ")
+	}
+	for _, title := range officerTitles {
+		if strings.HasPrefix(s, title) {
+			return title, strings.Trim(s[len(title):], "　 ")
+		}
+	}
+	return "", s
+}
+
+func parseOfficer(lines []string) (Officer, bool) {
+	var left []string
+	var right strings.Builder
+	for _, line := range lines {
+		l, r := line, ""
+		if i := strings.Index(line, "│"); i >= 0 {
+			l, r = line[:i], line[i+len("│"):]
+		} else if i := strings.Index(line, "├"); i >= 0 {
+			l = line[:i]
+		}
+		if r == "" {
+			// 2列の区画では日付も同じ列に書かれる
+			var registeredDate string
+			l, registeredDate = splitRegisteredDate(l)
+			if registeredDate != "" {
+				right.WriteString(registeredDate + "登記")
+			}
+			if body, date, event := splitEventDate(l); date != "" {
+				l = body
+				right.WriteString(date + event)
+			}
+		}
+		if l = strings.Trim(l, "　 ┃┨"); l != "" {
+			left = append(left, l)
+		}
+		if !strings.HasPrefix(strings.TrimLeft(r, "　 "), "├") {
+			right.WriteString(strings.Trim(r, "　 ┃┨"))
+		}
+	}
+	if len(left) == 0 {
+		return Officer{}, false
+	}
+
+	var o Officer
+	if len(left) > 1 && addressStartRegex.MatchString(left[0]) {
+		o.Address = ZenkakuToHankaku(left[0])
+		left = left[1:]
+	}
+	o.Title, o.Name = splitTitleName(strings.Join(left, "　　"))
+
+	text, registeredDate := splitRegisteredDate(right.String())
+	_, o.Date, o.Event = splitEventDate(text)
+	o.RegisteredDate = registeredDate
+	return o, true
+}
+
+func (h *Houjin) ReadOfficers() error {
+	h.Officers = nil
+	for _, s := range findSections(h.Parts, "役員に関する") {
+		for _, entry := range s.entries() {
+			if o, ok := parseOfficer(entry); ok {
+				h.Officers = append(h.Officers, o)
+			}
+		}
+	}
+	return nil
+}
+
+// CurrentOfficers は最後の登記が就任または重任である役員を返す
+func (h *Houjin) CurrentOfficers() []Officer {
+	type key struct{ title, name string }
+	last := make(map[key]int)
+	var order []key
+	for i, o := range h.Officers {
+		k := key{o.Title, normalize.MatchKey(o.Name)}
+		if _, ok := last[k]; !ok {
+			order = append(order, k)
+		}
+		last[k] = i
+	}
+	var current []Officer
+	for _, k := range order {
+		o := h.Officers[last[k]]
+		if o.Event == "就任" || o.Event == "重任" || o.Event == "" {
+			current = append(current, o)
+		}
+	}
+	return current
+}
+
+// Representatives は現在の代表者を返す
+func (h *Houjin) Representatives() []Officer {
+	var reps []Officer
+	for _, o := range h.CurrentOfficers() {
+		if o.Representative() {
+			reps = append(reps, o)
+		}
+	}
+	return reps
+}
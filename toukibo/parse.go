@@ -3,6 +3,7 @@ package toukibo
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -10,34 +11,42 @@ import (
 const (
 	beginContent = "┏━━━━━━━━┯━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━┓"
 	endContent   = "┗━━━━━━━━┷━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━┛"
-	separator1   = "┠────────┼─────────────────────────────────────┨"
-	separator2   = "┣━━━━━━━━┿━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━┫"
-	separator3   = "┣━━━━━━━━┿━━━━━━━━━━━━━━━━━━━━━━━┯━━━━━━━━━━━━━┫"
-	separator4   = "┣━━━━━━━━┿━━━━━━━━━━━━━━━━━━━━━━━┷━━━━━━━━━━━━━┫"
-	separator5   = "┠────────┼───────────────────────┴─────────────┨"
 )
 
 type ToukiboHeader struct {
-	CreatedAt      time.Time
+	CreatedAt      time.Time // 日本時間
+	Page           int       // 頁番号。書かれていなければ0
+	TotalPages     int
 	CompanyAddress string
 	CompanyName    string
+	Kankatsu       string // 管轄する法務局、支局または出張所
+	Office         string // 証明書を作成した登記所
+	Toukikan       string // 登記官
+	ShoumeiDate    string // 証明日
 }
 
-func ReadCreatedAt(s string) (time.Time, error) {
-	// 正規表現パターン: 全角数字で構成された日付と時刻
-	pattern := "([０-９]{2,4}／[０-９]{1,2}／[０-９]{1,2})　*([０-９]{1,2}：[０-９]{1,2})"
-	regex := regexp.MustCompile(pattern)
+var jst = time.FixedZone("JST", 9*60*60)
+
+var (
+	createdAtRegex  = regexp.MustCompile("([０-９0-9]{2,4}[／/][０-９0-9]{1,2}[／/][０-９0-9]{1,2})[　 ]*([０-９0-9]{1,2}[：:][０-９0-9]{1,2})")
+	headerSepRegex  = regexp.MustCompile(`[　 ]{2,}`)
+	pageRegex       = regexp.MustCompile(`^([０-９0-9]+)[／/]([０-９0-9]+)$`)
+	kankatsuRegex   = regexp.MustCompile(`[（(]([^（()）]+)管轄[）)]`)
+	toukikanRegex   = regexp.MustCompile(`登記官[　 ]*([^ 　](?:[^ 　]|　[^ 　])*)`)
+	headerNoteWords = []string{"現在の情報です", "事項全部証明書", "事項一部証明書", "全部事項証明書", "一部事項証明書"}
+)
 
-	// 抽出された日付と時刻を表示
-	matches := regex.FindStringSubmatch(s)
+func ReadCreatedAt(s string) (time.Time, error) {
+	// 全角数字で構成された日付と時刻
+	matches := createdAtRegex.FindStringSubmatch(s)
 	if len(matches) > 0 {
 		// 全角数字を半角数字に変換
-		dateStr := zenkakuToHankaku(matches[1])
-		timeStr := zenkakuToHankaku(matches[2])
+		dateStr := ZenkakuToHankaku(matches[1])
+		timeStr := ZenkakuToHankaku(matches[2])
 
-		// 日付と時刻を time.Time 型に変換
-		layout := "2006/01/02 15:04"
-		dt, err := time.Parse(layout, fmt.Sprintf("%s %s", dateStr, timeStr))
+		// 日付と時刻を日本時間の time.Time 型に変換
+		layout := "2006/1/2 15:4"
+		dt, err := time.ParseInLocation(layout, fmt.Sprintf("%s %s", dateStr, timeStr), jst)
 		if err != nil {
 			return time.Time{}, fmt.Errorf("日付と時刻の変換に失敗しました: %w", err)
 		}
@@ -47,10 +56,37 @@ func ReadCreatedAt(s string) (time.Time, error) {
 	}
 }
 
+// headerTokens は見出しや末尾の文字列を、2文字以上続く空白（「 　」など）で区切る。
+// 1文字だけの空白は「ABC Holdings Inc.」のような商号や住所の一部なので区切らない
+func headerTokens(s string) []string {
+	var tokens []string
+	for _, t := range headerSepRegex.Split(s, -1) {
+		if t = strings.Trim(t, "　 "); t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+func readPage(header *ToukiboHeader, token string) bool {
+	m := pageRegex.FindStringSubmatch(token)
+	if m == nil {
+		return false
+	}
+	page, err1 := strconv.Atoi(ZenkakuToHankaku(m[1]))
+	total, err2 := strconv.Atoi(ZenkakuToHankaku(m[2]))
+	if err1 != nil || err2 != nil || page > total {
+		return false
+	}
+	header.Page, header.TotalPages = page, total
+	return true
+}
+
 type ToukiboContent struct {
 	Header *ToukiboHeader
 
 	HeaderString string
+	FooterString string
 	Content      string
 	Parts        []string
 }
@@ -93,16 +129,11 @@ func DivideToukiboContent(input string) (ToukiboContent, error) {
 	}
 	tc.HeaderString = header
 	tc.Content = content
+	if i := strings.LastIndex(input, "┛"); i >= 0 {
+		tc.FooterString = input[i+len("┛"):]
+	}
 
-	separatorPattern := fmt.Sprintf("%s|%s|%s|%s|%s", separator1, separator2, separator3, separator4, separator5)
-	re := regexp.MustCompile(separatorPattern)
-
-	parts := re.Split(tc.Content, -1)
-
-	//for i, part := range parts {
-	//	fmt.Printf("Part %d: \n%s\n", i, part)
-	//}
-	tc.Parts = parts
+	tc.Parts = SplitParts(tc.Content)
 
 	return tc, nil
 }
@@ -123,19 +154,71 @@ func CleanHoujinNamePart(s string) string {
 	return cleanedText
 }
 
+// ParseHeader は証明書の見出しから作成日時、頁、本店と商号を読み取る。
+// 見つからない項目は空のままにする
 func ParseHeader(s string) (*ToukiboHeader, error) {
-	arr := strings.Split(s, " 　")
 	header := ToukiboHeader{}
-	createdAt, err := ReadCreatedAt(arr[0])
-	if err != nil {
-		return nil, err
+	var rest []string
+	for _, t := range headerTokens(s) {
+		if loc := createdAtRegex.FindStringIndex(t); loc != nil && header.CreatedAt.IsZero() {
+			createdAt, err := ReadCreatedAt(t)
+			if err != nil {
+				return nil, err
+			}
+			header.CreatedAt = createdAt
+			t = strings.Trim(t[:loc[0]]+t[loc[1]:], "　 ")
+		}
+		if t == "" || readPage(&header, t) || containsAny(t, headerNoteWords) {
+			continue
+		}
+		rest = append(rest, t)
+	}
+
+	// 本店、商号の順に書かれる。本店は都道府県から書かれるので、それを手がかりにする。
+	// 商号の中に空白が続いて分かれたときは、残りをつなげて商号とする
+	at := 0
+	for i, t := range rest {
+		if addressStartRegex.MatchString(t) {
+			at = i
+			break
+		}
+	}
+	switch n := len(rest) - at; {
+	case n <= 0:
+	case n == 1:
+		header.CompanyName = rest[at]
+	default:
+		header.CompanyAddress = rest[at]
+		header.CompanyName = strings.Join(rest[at+1:], " ")
 	}
-	header.CreatedAt = createdAt
-	header.CompanyAddress = arr[2]
-	header.CompanyName = arr[3]
 	return &header, nil
 }
 
+// ParseFooter は証明書の末尾から管轄登記所、登記官、証明日と頁を読み取る
+func ParseFooter(s string, header *ToukiboHeader) {
+	if m := kankatsuRegex.FindStringSubmatch(s); m != nil {
+		header.Kankatsu = strings.Trim(m[1], "　 ")
+		s = strings.Replace(s, m[0], "", 1)
+	}
+	if m := toukikanRegex.FindStringSubmatch(s); m != nil {
+		header.Toukikan = m[1]
+		s = strings.Replace(s, m[0], "", 1)
+	}
+	if d := WarekiRegex.FindString(s); d != "" {
+		header.ShoumeiDate = NormalizeDate(d)
+	}
+	if office := officeRegex.FindString(s); office != "" {
+		header.Office = office
+		if header.Kankatsu == "" {
+			// 管轄の登記所で作成された証明書には管轄が書かれない
+			header.Kankatsu = office
+		}
+	}
+	for _, t := range headerTokens(s) {
+		readPage(header, t)
+	}
+}
+
 func Parse(input string) (ToukiboContent, error) {
 	tc, err := DivideToukiboContent(input)
 	if err != nil {
@@ -145,7 +228,209 @@ func Parse(input string) (ToukiboContent, error) {
 	if err != nil {
 		return tc, err
 	}
+	ParseFooter(tc.FooterString, header)
 	tc.Header = header
 
 	return tc, nil
 }
+
+const (
+	warekiPattern     = `(?:明治|大正|昭和|平成|令和)[　 ]*(?:[０-９0-9]{1,2}|元)年[　 ]*[０-９0-9]{1,2}月[　 ]*[０-９0-9]{1,2}日`
+	prefecturePattern = `(?:北海道|東京都|京都府|大阪府|\p{Han}{2,3}県)`
+)
+
+var (
+	// WarekiRegex は「令和５年　４月　１日」のような和暦の日付に一致する
+	WarekiRegex = regexp.MustCompile(warekiPattern)
+	// PrefectureRegex は住所の書き出しの都道府県名に一致する
+	PrefectureRegex = regexp.MustCompile(prefecturePattern)
+
+	registeredDateRegex = regexp.MustCompile(`(` + warekiPattern + `)登記`)
+	eventDateRegex      = regexp.MustCompile(`(` + warekiPattern + `)(変更|追加|設定|廃止|移転|就任|重任|辞任|退任|死亡|解任|資格喪失)`)
+	rowSeparatorRegex   = regexp.MustCompile(`[┃┨] ┃`)
+	addressEndRegex     = regexp.MustCompile(`[０-９0-9一二三四五六七八九十百千]+(?:番地|番|号|丁目)(?:の?[０-９0-9]+(?:号)?)?`)
+)
+
+// Row は証明書の表の1行を、左側の項目名と右側の内容に分けたもの
+type Row struct {
+	Label string
+	Value string
+}
+
+// section は項目名が同じ行をまとめたもの
+type section struct {
+	label string
+	lines []string
+}
+
+// tableRuleRegex は表の横罫線（┠──┼──┨、┣━━┿━━┫など）に一致する
+var tableRuleRegex = regexp.MustCompile(`[┏┠┣┗][─━┯┼┿┷┴┬┳┻╋－]*[┓┨┫┛]`)
+
+// SplitParts は罫線で描かれた表を横罫線で区画に分ける。
+// 商業登記と不動産登記の証明書で共通に使う
+func SplitParts(content string) []string {
+	return tableRuleRegex.Split(content, -1)
+}
+
+// SplitRows は区画を行に分ける。区画の中の罫線（├──┨）は内容の一部として残す
+func SplitRows(part string) []Row {
+	part = strings.TrimSpace(part)
+	part = strings.TrimPrefix(part, "┃")
+	part = strings.TrimSuffix(part, "┃")
+
+	var rows []Row
+	for _, line := range rowSeparatorRegex.Split(part, -1) {
+		i := strings.IndexAny(line, "│├")
+		if i < 0 {
+			rows = append(rows, Row{Value: line})
+			continue
+		}
+		r := Row{Label: strings.TrimSpace(line[:i])}
+		if strings.HasPrefix(line[i:], "│") {
+			r.Value = line[i+len("│"):]
+		} else {
+			r.Value = line[i:]
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+func newSection(part string) section {
+	var s section
+	for _, r := range SplitRows(part) {
+		s.label += strings.NewReplacer("　", "", " ", "").Replace(r.Label)
+		s.lines = append(s.lines, r.Value)
+	}
+	return s
+}
+
+// findSections は項目名にlabelを含む区画を全て返す
+func findSections(parts []string, label string) []section {
+	var sections []section
+	for _, part := range parts {
+		s := newSection(part)
+		if strings.Contains(s.label, label) {
+			sections = append(sections, s)
+		}
+	}
+	return sections
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// entries は区画の内容を罫線（├─）ごとの登記事項に分割する
+func (s section) entries() [][]string {
+	var entries [][]string
+	var cur []string
+	for _, line := range s.lines {
+		if strings.HasPrefix(strings.TrimLeft(line, "　 "), "├─") {
+			if len(cur) > 0 {
+				entries = append(entries, cur)
+			}
+			cur = nil
+			continue
+		}
+		cur = append(cur, line)
+	}
+	if len(cur) > 0 {
+		entries = append(entries, cur)
+	}
+	return entries
+}
+
+// joinLines は複数行にまたがる内容を1つの文字列につなげる
+func joinLines(lines []string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		for _, cell := range strings.Split(line, "│") {
+			if strings.HasPrefix(strings.TrimLeft(cell, "　 "), "├") {
+				continue
+			}
+			b.WriteString(strings.Trim(cell, "　 ┃┨"))
+		}
+	}
+	return b.String()
+}
+
+// NormalizeDate は「平成２０年　７月２５日」を「平成20年7月25日」の形にそろえる
+func NormalizeDate(s string) string {
+	s = ZenkakuToHankaku(s)
+	return strings.NewReplacer(" ", "", "　", "").Replace(s)
+}
+
+// splitRegisteredDate は末尾の「…登記」の日付を取り除き、本文と登記日を返す
+func splitRegisteredDate(s string) (string, string) {
+	loc := registeredDateRegex.FindAllStringSubmatchIndex(s, -1)
+	if len(loc) == 0 {
+		return s, ""
+	}
+	last := loc[len(loc)-1]
+	return s[:last[0]] + s[last[1]:], NormalizeDate(s[last[2]:last[3]])
+}
+
+// splitEventDate は「…変更」「…就任」などの日付を取り除き、本文と日付、事由を返す
+func splitEventDate(s string) (string, string, string) {
+	loc := eventDateRegex.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return s, "", ""
+	}
+	return s[:loc[0]] + s[loc[1]:], NormalizeDate(s[loc[2]:loc[3]]), s[loc[4]:loc[5]]
+}
+
+// splitLeadingDate は先頭の日付を取り除き、本文と日付を返す
+func splitLeadingDate(s string) (string, string) {
+	loc := WarekiRegex.FindStringIndex(s)
+	if loc == nil || loc[0] != 0 {
+		return s, ""
+	}
+	return s[loc[1]:], NormalizeDate(s[:loc[1]])
+}
+
+// SplitAddressName は「東京都…１番１号株式会社ＡＢＣ」のような文字列を住所と名称に分ける
+func SplitAddressName(s string) (string, string) {
+	limit := len(s)
+	if k := indexHoujinKaku(s); k >= 0 {
+		limit = k
+	}
+	loc := addressEndRegex.FindAllStringIndex(s[:limit], -1)
+	if len(loc) == 0 {
+		if limit < len(s) && limit > 0 {
+			return s[:limit], s[limit:]
+		}
+		return "", s
+	}
+	end := loc[len(loc)-1][1]
+	return s[:end], s[end:]
+}
+
+// innerRuleRegex は区画の中の罫線（├──┨）に一致する
+var innerRuleRegex = regexp.MustCompile(`├[─━┼┴┬┿┷┯－]*[┨┤]?`)
+
+// Cells は行を「│」で区切られたセルに分け、各セルの前後の空白を取り除く。
+// 最初のセルは項目名で、罫線だけのセルは空にする
+func (r Row) Cells() []string {
+	cells := []string{strings.Trim(r.Label, "┃ 　")}
+	for _, c := range strings.Split(r.Value, "│") {
+		c = innerRuleRegex.ReplaceAllString(c, "")
+		cells = append(cells, strings.Trim(c, "┃┨ 　"))
+	}
+	return cells
+}
+
+// IsBlank は行のどのセルにも文字がなければtrueを返す
+func (r Row) IsBlank() bool {
+	for _, c := range r.Cells() {
+		if c != "" {
+			return false
+		}
+	}
+	return true
+}
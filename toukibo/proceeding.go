@@ -0,0 +1,194 @@
+package toukibo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type ProceedingKind string
+
+const (
+	ProceedingHasan           ProceedingKind = "破産手続"
+	ProceedingMinjiSaisei     ProceedingKind = "民事再生手続"
+	ProceedingKaishaKousei    ProceedingKind = "会社更生手続"
+	ProceedingTokubetsuSeisan ProceedingKind = "特別清算"
+)
+
+// Appointee は裁判所の命令により選任された者
+type Appointee struct {
+	Role    string
+	Name    string
+	Address string
+}
+
+// Proceeding は裁判所の嘱託による倒産手続の登記
+type Proceeding struct {
+	Kind           ProceedingKind
+	Court          string
+	Date           string // 開始決定の日
+	RegisteredDate string
+	ClosedDate     string // 廃止・終結等の日。手続中であれば空
+	ClosedReason   string
+	Appointees     []Appointee
+	Raw            string
+}
+
+func (p Proceeding) String() string {
+	s := fmt.Sprintf("%s %s %s開始", p.Date, p.Court, p.Kind)
+	if p.ClosedDate != "" {
+		s += fmt.Sprintf("（%s %s）", p.ClosedDate, p.ClosedReason)
+	}
+	for _, a := range p.Appointees {
+		s += fmt.Sprintf(" %s: %s", a.Role, a.Name)
+	}
+	return s
+}
+
+// Ongoing は手続が終わっていなければtrueを返す
+func (p Proceeding) Ongoing() bool {
+	return p.ClosedDate == ""
+}
+
+var proceedingPhrases = []struct {
+	phrase string
+	kind   ProceedingKind
+}{
+	{"破産手続開始", ProceedingHasan},
+	{"再生手続開始", ProceedingMinjiSaisei},
+	{"更生手続開始", ProceedingKaishaKousei},
+	{"特別清算開始", ProceedingTokubetsuSeisan},
+}
+
+var proceedingClosePhrases = []struct {
+	phrase string
+	kind   ProceedingKind
+}{
+	{"破産手続廃止", ProceedingHasan},
+	{"破産手続終結", ProceedingHasan},
+	{"破産手続開始決定取消", ProceedingHasan},
+	{"再生手続廃止", ProceedingMinjiSaisei},
+	{"再生手続終結", ProceedingMinjiSaisei},
+	{"再生手続開始決定取消", ProceedingMinjiSaisei},
+	{"更生手続廃止", ProceedingKaishaKousei},
+	{"更生手続終結", ProceedingKaishaKousei},
+	{"更生手続開始決定取消", ProceedingKaishaKousei},
+	{"特別清算終結", ProceedingTokubetsuSeisan},
+	{"特別清算開始取消", ProceedingTokubetsuSeisan},
+}
+
+// 長い語句から順に照合する
+var appointeeRoles = []struct {
+	role string
+	kind ProceedingKind
+}{
+	{"破産管財人", ProceedingHasan},
+	{"保全管理人", ""},
+	{"監督委員", ProceedingMinjiSaisei},
+	{"調査委員", ""},
+	{"管財人", ProceedingKaishaKousei},
+}
+
+var (
+	courtTimeRegex     = regexp.MustCompile(`^(?:午前|午後)?[０-９0-9]{1,2}時(?:[０-９0-9]{1,2}分)?`)
+	courtRegex         = regexp.MustCompile(`^\p{Han}+?裁判所(?:\p{Han}+?支部)?`)
+	courtFallbackRegex = regexp.MustCompile(`\p{Han}{2,4}(?:地方|高等|簡易)裁判所(?:\p{Han}{1,6}?支部)?`)
+	professionRegex    = regexp.MustCompile(`^(?:弁護士|公認会計士|税理士|司法書士)`)
+)
+
+func readCourt(body string) string {
+	body = courtTimeRegex.ReplaceAllString(body, "")
+	if court := courtRegex.FindString(body); court != "" {
+		return court
+	}
+	return courtFallbackRegex.FindString(body)
+}
+
+func parseAppointee(role, s string) Appointee {
+	address, name := SplitAddressName(strings.Trim(s, "　 "))
+	name = professionRegex.ReplaceAllString(strings.Trim(name, "　 "), "")
+	return Appointee{
+		Role:    role,
+		Name:    strings.Trim(name, "　 "),
+		Address: ZenkakuToHankaku(strings.Trim(address, "　 ")),
+	}
+}
+
+// lastProceeding は指定した種類の直近の手続を返す。kindが空の場合は種類を問わない
+func (h *Houjin) lastProceeding(kind ProceedingKind) *Proceeding {
+	for i := len(h.Proceedings) - 1; i >= 0; i-- {
+		if kind == "" || h.Proceedings[i].Kind == kind {
+			return &h.Proceedings[i]
+		}
+	}
+	return nil
+}
+
+func (h *Houjin) readProceedingEntry(text string) {
+	body, registeredDate := splitRegisteredDate(text)
+	body, date := splitLeadingDate(body)
+
+	// 「破産手続開始決定取消」などを開始と取り違えないよう、先に終了を調べる
+	for _, p := range proceedingClosePhrases {
+		if !strings.Contains(body, p.phrase) {
+			continue
+		}
+		if last := h.lastProceeding(p.kind); last != nil {
+			last.ClosedDate = date
+			last.ClosedReason = p.phrase
+		}
+		return
+	}
+
+	for _, p := range proceedingPhrases {
+		if !strings.Contains(body, p.phrase) {
+			continue
+		}
+		h.Proceedings = append(h.Proceedings, Proceeding{
+			Kind:           p.kind,
+			Court:          readCourt(body),
+			Date:           date,
+			RegisteredDate: registeredDate,
+			Raw:            text,
+		})
+		return
+	}
+
+	for _, a := range appointeeRoles {
+		i := strings.Index(body, a.role)
+		if i < 0 {
+			continue
+		}
+		last := h.lastProceeding(a.kind)
+		if last == nil {
+			last = h.lastProceeding("")
+		}
+		if last == nil {
+			return
+		}
+		last.Appointees = append(last.Appointees, parseAppointee(a.role, body[i+len(a.role):]))
+		return
+	}
+}
+
+func (h *Houjin) ReadProceedings() error {
+	h.Proceedings = nil
+	for _, part := range h.Parts {
+		s := newSection(part)
+		if strings.Contains(s.label, "目的") {
+			continue
+		}
+		for _, entry := range s.entries() {
+			text := joinLines(entry)
+			// 項目名が「破産管財人」などの場合は内容の前に補う
+			for _, a := range appointeeRoles {
+				if strings.Contains(s.label, a.role) && !strings.Contains(text, a.role) {
+					text = a.role + text
+					break
+				}
+			}
+			h.readProceedingEntry(text)
+		}
+	}
+	return nil
+}
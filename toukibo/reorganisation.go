@@ -0,0 +1,140 @@
+package toukibo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type ReorganisationKind string
+
+const (
+	ReorganisationKyushuGappei    ReorganisationKind = "吸収合併"
+	ReorganisationShinsetsuGappei ReorganisationKind = "新設合併"
+	ReorganisationBunkatsu        ReorganisationKind = "会社分割"
+	ReorganisationSoshikiHenko    ReorganisationKind = "組織変更"
+	ReorganisationIkou            ReorganisationKind = "特例有限会社からの移行"
+	ReorganisationKabusikiKoukan  ReorganisationKind = "株式交換"
+	ReorganisationKabusikiIten    ReorganisationKind = "株式移転"
+)
+
+// CounterpartyRole は相手方の会社がこの会社から見てどういう立場にあるかを表す
+type CounterpartyRole string
+
+const (
+	CounterpartyPredecessor CounterpartyRole = "前身"    // 相手方の権利義務をこの会社が承継した
+	CounterpartySuccessor   CounterpartyRole = "承継先"   // この会社の権利義務を相手方が承継した
+	CounterpartySubsidiary  CounterpartyRole = "完全子会社" // 株式移転で相手方がこの会社の子会社になった
+	CounterpartyOther       CounterpartyRole = "相手方"
+)
+
+// Counterparty は組織再編の相手方の会社
+type Counterparty struct {
+	Name    string
+	Address string
+}
+
+type Reorganisation struct {
+	Kind           ReorganisationKind
+	Role           CounterpartyRole
+	Counterparties []Counterparty
+	EffectiveDate  string // 効力発生日
+	RegisteredDate string // 登記日
+	Raw            string
+}
+
+type reorganisationRule struct {
+	phrase string
+	kind   ReorganisationKind
+	role   CounterpartyRole
+}
+
+// 長い語句から順に照合する
+var reorganisationRules = []reorganisationRule{
+	{"を商号変更し、移行したことにより設立", ReorganisationIkou, CounterpartyPredecessor},
+	{"に商号変更し、移行したことにより解散", ReorganisationIkou, CounterpartySuccessor},
+	{"の株式移転により設立", ReorganisationKabusikiIten, CounterpartySubsidiary},
+	{"の合併により設立", ReorganisationShinsetsuGappei, CounterpartyPredecessor},
+	{"から分割により設立", ReorganisationBunkatsu, CounterpartyPredecessor},
+	{"を組織変更し設立", ReorganisationSoshikiHenko, CounterpartyPredecessor},
+	{"に組織変更し解散", ReorganisationSoshikiHenko, CounterpartySuccessor},
+	{"に合併し解散", ReorganisationKyushuGappei, CounterpartySuccessor},
+	{"を合併", ReorganisationKyushuGappei, CounterpartyPredecessor},
+	{"から分割", ReorganisationBunkatsu, CounterpartyPredecessor},
+	{"に分割", ReorganisationBunkatsu, CounterpartySuccessor},
+	{"と株式交換", ReorganisationKabusikiKoukan, CounterpartyOther},
+	{"に株式交換", ReorganisationKabusikiKoukan, CounterpartyOther},
+}
+
+// 組織再編が記録される区画の項目名
+var reorganisationLabels = []string{"合併", "分割", "株式交換", "株式移転", "組織変更", "登記記録に関する"}
+
+var counterpartySeparatorRegex = regexp.MustCompile(`及び|、|と(?:` + prefecturePattern + `)`)
+
+func (r Reorganisation) String() string {
+	var names []string
+	for _, c := range r.Counterparties {
+		names = append(names, c.Name)
+	}
+	return fmt.Sprintf("%s %s（%s: %s）", r.EffectiveDate, r.Kind, r.Role, strings.Join(names, "、"))
+}
+
+func splitCounterparties(s string) []Counterparty {
+	var counterparties []Counterparty
+	for len(s) > 0 {
+		loc := counterpartySeparatorRegex.FindStringIndex(s)
+		end, next := len(s), len(s)
+		if loc != nil {
+			end, next = loc[0], loc[1]
+			if strings.HasPrefix(s[loc[0]:], "と") {
+				// 「と」の後ろの都道府県名は次の相手方の住所に含める
+				next = loc[0] + len("と")
+			}
+		}
+		if part := strings.TrimSpace(s[:end]); part != "" {
+			address, name := SplitAddressName(part)
+			counterparties = append(counterparties, Counterparty{
+				Name:    strings.TrimSpace(name),
+				Address: ZenkakuToHankaku(strings.TrimSpace(address)),
+			})
+		}
+		s = s[next:]
+	}
+	return counterparties
+}
+
+func parseReorganisation(text string) (Reorganisation, bool) {
+	body, registeredDate := splitRegisteredDate(text)
+	body, effectiveDate := splitLeadingDate(body)
+	for _, rule := range reorganisationRules {
+		i := strings.Index(body, rule.phrase)
+		if i < 0 {
+			continue
+		}
+		return Reorganisation{
+			Kind:           rule.kind,
+			Role:           rule.role,
+			Counterparties: splitCounterparties(body[:i]),
+			EffectiveDate:  effectiveDate,
+			RegisteredDate: registeredDate,
+			Raw:            text,
+		}, true
+	}
+	return Reorganisation{}, false
+}
+
+func (h *Houjin) ReadReorganisations() error {
+	h.Reorganisations = nil
+	for _, part := range h.Parts {
+		s := newSection(part)
+		if !containsAny(s.label, reorganisationLabels) {
+			continue
+		}
+		for _, entry := range s.entries() {
+			if r, ok := parseReorganisation(joinLines(entry)); ok {
+				h.Reorganisations = append(h.Reorganisations, r)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package toukibo
+
+import "strings"
+
+type HoujinStatus string
+
+const (
+	HoujinStatusActive    HoujinStatus = "存続"
+	HoujinStatusDissolved HoujinStatus = "解散"
+	HoujinStatusClosed    HoujinStatus = "閉鎖"
+	HoujinStatusHasan     HoujinStatus = "破産手続中"
+	HoujinStatusSaisei    HoujinStatus = "民事再生手続中"
+	HoujinStatusKousei    HoujinStatus = "会社更生手続中"
+	HoujinStatusTokubetsu HoujinStatus = "特別清算中"
+)
+
+var proceedingStatus = map[ProceedingKind]HoujinStatus{
+	ProceedingHasan:           HoujinStatusHasan,
+	ProceedingMinjiSaisei:     HoujinStatusSaisei,
+	ProceedingKaishaKousei:    HoujinStatusKousei,
+	ProceedingTokubetsuSeisan: HoujinStatusTokubetsu,
+}
+
+// ComputeStatus は登記記録と倒産手続の登記から現在の状態を判定する。
+// ReadToukiJikou、ReadToukiKiroku と ReadProceedings の後に呼ぶ必要がある
+func (h *Houjin) ComputeStatus() HoujinStatus {
+	status := HoujinStatusActive
+	if len(findSections(h.Parts, "解散")) > 0 {
+		status = HoujinStatusDissolved
+	}
+	for i := len(h.Proceedings) - 1; i >= 0; i-- {
+		if h.Proceedings[i].Ongoing() {
+			status = proceedingStatus[h.Proceedings[i].Kind]
+			break
+		}
+	}
+	if h.Closure() != nil || strings.Contains(h.ToukiJiko, "閉鎖") {
+		status = HoujinStatusClosed
+	}
+	h.Status = status
+	return status
+}
@@ -1,5 +1,7 @@
 package toukibo
 
+import "vandal/normalize"
+
 const (
 	ZenkakuZero          = '０'
 	ZenkakuNine          = '９'
@@ -11,29 +13,12 @@ const (
 	ZenkakuColon         = '：'
 	ZenkakuSlash         = '／'
 	ZenkakuHyphen        = '－'
+	ZenkakuPeriod        = '．'
 	ZenkakuStringPattern = `\p{Han}\p{Hiragana}\p{Katakana}Ａ-Ｚａ-ｚ０-９A-Za-z0-9＆’，‐．・ー\s　。－`
 )
 
-func zenkakuToHankaku(s string) string {
-	var result string
-	for _, r := range s {
-		if r >= ZenkakuZero && r <= ZenkakuNine {
-			result += string(r - ZenkakuZero + '0')
-		} else if r >= ZenkakuA && r <= ZenkakuZ {
-			result += string(r - ZenkakuA + 'A')
-		} else if r >= ZenkakuSmallA && r <= ZenkakuSmallZ {
-			result += string(r - ZenkakuSmallA + 'a')
-		} else if r == ZenkakuSlash {
-			result += "/"
-		} else if r == ZenkakuColon {
-			result += ":"
-		} else if r == ZenkakuSpace {
-			result += " "
-		} else if r == ZenkakuHyphen {
-			result += "-"
-		} else {
-			result += string(r)
-		}
-	}
-	return result
+// ZenkakuToHankaku は全角英数字・記号と全角空白を半角にする。
+// 元の文字列は Content に残るので、表示にはそちらを使う
+func ZenkakuToHankaku(s string) string {
+	return normalize.Width(s)
 }